@@ -0,0 +1,106 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+	"github.com/primasio/primas-node/db"
+	"github.com/primasio/primas-node/incentives"
+)
+
+// runCommand runs a one-off command instead of the node and returns the
+// exit code.
+func runCommand(args []string) int {
+
+	if len(args) >= 2 && args[0] == "incentives" && args[1] == "simulate" {
+		return simulateIncentives(args[2:])
+	}
+
+	fmt.Println("unknown command: " + strings.Join(args, " "))
+	flag.Usage()
+
+	return 1
+}
+
+func simulateIncentives(args []string) int {
+
+	flags := flag.NewFlagSet("incentives simulate", flag.ContinueOnError)
+
+	amountStr := flags.String("amount", "", "incentives to distribute in wei")
+	format := flags.String("format", "json", "output format: json or csv")
+	ranking := flags.String("ranking", "", "ranking strategy, defaults to the configured one")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	amount, ok := new(big.Int).SetString(*amountStr, 10)
+
+	if !ok || amount.Sign() < 0 {
+		log.Println("invalid amount: " + *amountStr)
+		return 1
+	}
+
+	policy, err := incentives.LoadPolicy()
+
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	if *ranking != "" {
+
+		policy.Ranking = *ranking
+
+		if err := policy.Validate(); err != nil {
+			log.Println(err)
+			return 1
+		}
+	}
+
+	simulation, err := incentives.Simulate(amount, policy, db.GetDb())
+
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	switch *format {
+	case "csv":
+		err = simulation.WriteCSV(os.Stdout)
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(simulation)
+	default:
+		log.Println("unknown format: " + *format)
+		return 1
+	}
+
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	return 0
+}
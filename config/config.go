@@ -52,6 +52,13 @@ func relativePath(basedir string, path *string) {
 	}
 }
 
+// ResolvePath resolves a path found in the configuration file. Relative
+// paths are taken relative to the directory of the configuration file.
+func ResolvePath(path string) string {
+	relativePath(filepath.Dir(config.ConfigFileUsed()), &path)
+	return path
+}
+
 func GetConfig() *viper.Viper {
 	return config
 }
\ No newline at end of file
@@ -0,0 +1,373 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package contracts
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/primasio/primas-node/config"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// ABIRequirement lists the methods and events, with their input types,
+// that the node relies on for a contract.
+type ABIRequirement struct {
+	Methods map[string][]string
+	Events  map[string][]string
+}
+
+// Every method called by the contract wrappers and every event decoded by
+// the event handlers must be present in the configured ABI.
+var abiRequirements = map[string]ABIRequirement{
+	"metadata": {
+		Events: map[string][]string{
+			"PublishLog": {"bytes", "bytes", "bytes", "bytes", "bytes", "bytes", "bytes"},
+			"LikeLog":    {"bytes", "bytes", "bytes"},
+			"CommentLog": {"bytes", "bytes", "bytes", "bytes"},
+			"ShareLog":   {"bytes", "bytes", "bytes"},
+		},
+	},
+	"content": {
+		Methods: map[string][]string{
+			"publish": {"bytes", "bytes", "bytes", "bytes", "bytes", "bytes", "bytes", "address"},
+			"like":    {"bytes", "bytes", "bytes", "address"},
+			"comment": {"bytes", "bytes", "bytes", "bytes", "address"},
+			"share":   {"bytes", "bytes", "bytes", "address"},
+		},
+	},
+	"group": {
+		Methods: map[string][]string{
+			"create":              {"bytes", "bytes", "bytes", "bytes", "address"},
+			"addMember":           {"bytes", "bytes", "address"},
+			"removeMember":        {"bytes", "bytes", "address"},
+			"removeMemberByOwner": {"bytes", "address", "bytes", "address"},
+		},
+		Events: map[string][]string{
+			"CreateLog":              {"bytes", "bytes", "bytes"},
+			"AddMemberLog":           {"bytes", "bytes"},
+			"RemoveMemberLog":        {"bytes", "bytes"},
+			"RemoveMemberByOwnerLog": {"bytes", "address", "bytes"},
+		},
+	},
+	"user": {
+		Methods: map[string][]string{
+			"burn":           {"string", "bytes", "address"},
+			"updateUserName": {"string", "bytes", "address"},
+			"updateUserIcon": {"string", "bytes", "address"},
+		},
+		Events: map[string][]string{
+			"UserTokenBurnLog":  {"address", "uint256"},
+			"UserNameUpdateLog": {"address", "string"},
+			"UserIconUpdateLog": {"address", "string"},
+		},
+	},
+	"token": {
+		Methods: map[string][]string{
+			"inflate":           {},
+			"balanceOf":         {"address"},
+			"tokenTimeUnlock":   {"address", "bytes"},
+			"tokenStatusUnlock": {"address", "bytes"},
+			"tokenTimeLock":     {"address", "bytes", "uint256", "uint256"},
+			"tokenStatusLock":   {"address", "bytes", "uint256"},
+		},
+		Events: map[string][]string{
+			"Transfer":             {"address", "address", "uint256"},
+			"Inflate":              {"uint256"},
+			"Lock":                 {"address", "uint256", "bytes", "uint256", "uint256"},
+			"Approval":             {"address", "address", "uint256"},
+			"OwnershipTransferred": {"address", "address"},
+		},
+	},
+	"incentives": {
+		Methods: map[string][]string{
+			"grantIncentives": {"address[]", "uint256[]"},
+		},
+	},
+}
+
+type contractArtifact struct {
+	ABI      json.RawMessage `json:"abi"`
+	Address  string          `json:"address"`
+	Networks map[string]struct {
+		Address string `json:"address"`
+	} `json:"networks"`
+}
+
+// loadContractDeployments reads the deployments of a contract from its
+// configuration entry. A contract that has been redeployed lists each of
+// its deployments under "deployments", with the block range it was active
+// in. Otherwise the entry itself describes the only deployment.
+func loadContractDeployments(name string, itemMap map[string]interface{}) ([]*Deployment, error) {
+
+	items := []map[string]interface{}{itemMap}
+
+	if list, ok := itemMap["deployments"]; ok {
+
+		listItems, ok := list.([]interface{})
+
+		if !ok || len(listItems) == 0 {
+			return nil, errors.New("contract " + name + " deployments initialization failed")
+		}
+
+		items = nil
+
+		for _, listItem := range listItems {
+
+			deploymentMap, ok := toStringMap(listItem)
+
+			if !ok {
+				return nil, errors.New("contract " + name + " deployments initialization failed")
+			}
+
+			items = append(items, deploymentMap)
+		}
+	}
+
+	var deployments []*Deployment
+
+	for _, item := range items {
+
+		address, abiJson, err := loadContractDefinition(name, item)
+
+		if err != nil {
+			return nil, err
+		}
+
+		activeFromBlock, err := toBlockNumber(item["active_from_block"])
+
+		if err != nil {
+			return nil, errors.New("contract " + name + " active_from_block is invalid")
+		}
+
+		activeToBlock, err := toBlockNumber(item["active_to_block"])
+
+		if err != nil {
+			return nil, errors.New("contract " + name + " active_to_block is invalid")
+		}
+
+		deployment, err := NewDeployment(address, abiJson, activeFromBlock, activeToBlock)
+
+		if err != nil {
+			return nil, err
+		}
+
+		deployments = append(deployments, deployment)
+	}
+
+	return deployments, nil
+}
+
+func toStringMap(item interface{}) (map[string]interface{}, bool) {
+
+	switch m := item.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{})
+
+		for k, v := range m {
+			key, ok := k.(string)
+
+			if !ok {
+				return nil, false
+			}
+
+			result[strings.ToLower(key)] = v
+		}
+
+		return result, true
+	}
+
+	return nil, false
+}
+
+func toBlockNumber(value interface{}) (uint64, error) {
+
+	switch v := value.(type) {
+	case nil:
+		return 0, nil
+	case int:
+		if v < 0 {
+			return 0, errors.New("negative block number")
+		}
+		return uint64(v), nil
+	case int64:
+		if v < 0 {
+			return 0, errors.New("negative block number")
+		}
+		return uint64(v), nil
+	case uint64:
+		return v, nil
+	case string:
+		return strconv.ParseUint(v, 10, 64)
+	}
+
+	return 0, errors.New("invalid block number")
+}
+
+// loadContractDefinition reads the address and ABI of a contract from its
+// configuration entry. The ABI can be given inline with "abi", as a path to
+// an ABI JSON file with "abi_file", or as a path to a Truffle or Hardhat
+// build artifact with "artifact". An explicit "address" always wins over
+// the address found in an artifact.
+func loadContractDefinition(name string, itemMap map[string]interface{}) (address, abiJson string, err error) {
+
+	address, _ = itemMap["address"].(string)
+
+	if inline, ok := itemMap["abi"].(string); ok && inline != "" {
+		return address, inline, nil
+	}
+
+	if abiFile, ok := itemMap["abi_file"].(string); ok && abiFile != "" {
+
+		content, err := ioutil.ReadFile(config.ResolvePath(abiFile))
+
+		if err != nil {
+			return "", "", err
+		}
+
+		return address, string(content), nil
+	}
+
+	if artifactFile, ok := itemMap["artifact"].(string); ok && artifactFile != "" {
+
+		content, err := ioutil.ReadFile(config.ResolvePath(artifactFile))
+
+		if err != nil {
+			return "", "", err
+		}
+
+		artifact := &contractArtifact{}
+
+		if err := json.Unmarshal(content, artifact); err != nil {
+			return "", "", errors.New("contract " + name + " artifact is invalid: " + err.Error())
+		}
+
+		if len(artifact.ABI) == 0 {
+			return "", "", errors.New("contract " + name + " artifact has no abi")
+		}
+
+		if address == "" {
+			chainId := strconv.FormatInt(config.GetConfig().GetInt64("eth_node.chain_id"), 10)
+
+			if network, ok := artifact.Networks[chainId]; ok {
+				address = network.Address
+			} else {
+				address = artifact.Address
+			}
+		}
+
+		return address, string(artifact.ABI), nil
+	}
+
+	return "", "", errors.New("contract " + name + " abi initialization failed")
+}
+
+// ValidateABI checks that the ABI of the active deployment provides every
+// method and event required for the given contract name. Deployments that
+// are no longer active only need to provide the events, since their
+// history is still synchronized.
+func ValidateABI(name string, contract *Contract) error {
+
+	requirement, ok := abiRequirements[name]
+
+	if !ok {
+		return nil
+	}
+
+	for _, deployment := range contract.Deployments {
+
+		if deployment == contract.GetActiveDeployment() {
+			if err := validateMethods(name, deployment, requirement); err != nil {
+				return err
+			}
+		}
+
+		if err := validateEvents(name, deployment, requirement); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateMethods(name string, deployment *Deployment, requirement ABIRequirement) error {
+
+	for method, types := range requirement.Methods {
+
+		m, ok := deployment.ABI.Methods[method]
+
+		if !ok {
+			return errors.New("contract " + name + " abi is missing method " + method)
+		}
+
+		var actual []string
+
+		for _, input := range m.Inputs {
+			actual = append(actual, input.Type.String())
+		}
+
+		if !sameTypes(actual, types) {
+			return errors.New("contract " + name + " method " + method + " has inputs (" +
+				strings.Join(actual, ",") + "), expected (" + strings.Join(types, ",") + ")")
+		}
+	}
+
+	return nil
+}
+
+func validateEvents(name string, deployment *Deployment, requirement ABIRequirement) error {
+
+	for event, types := range requirement.Events {
+
+		e, ok := deployment.ABI.Events[event]
+
+		if !ok {
+			return errors.New("contract " + name + " abi at " + deployment.Address.Hex() + " is missing event " + event)
+		}
+
+		var actual []string
+
+		for _, input := range e.Inputs {
+			actual = append(actual, input.Type.String())
+		}
+
+		if !sameTypes(actual, types) {
+			return errors.New("contract " + name + " event " + event + " has inputs (" +
+				strings.Join(actual, ",") + "), expected (" + strings.Join(types, ",") + ")")
+		}
+	}
+
+	return nil
+}
+
+func sameTypes(actual, expected []string) bool {
+
+	if len(actual) != len(expected) {
+		return false
+	}
+
+	for i := range actual {
+		if actual[i] != expected[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,64 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package contracts_test
+
+import (
+	"testing"
+	"github.com/magiconair/properties/assert"
+	"github.com/primasio/primas-node/contracts"
+)
+
+const userABI = `[
+{"constant":false,"inputs":[{"name":"timestamp","type":"string"},{"name":"signature","type":"bytes"},{"name":"user","type":"address"}],"name":"burn","outputs":[],"payable":false,"type":"function"},
+{"constant":false,"inputs":[{"name":"name","type":"string"},{"name":"signature","type":"bytes"},{"name":"user","type":"address"}],"name":"updateUserName","outputs":[],"payable":false,"type":"function"},
+{"constant":false,"inputs":[{"name":"icon","type":"string"},{"name":"signature","type":"bytes"},{"name":"user","type":"address"}],"name":"updateUserIcon","outputs":[],"payable":false,"type":"function"},
+{"anonymous":false,"inputs":[{"indexed":false,"name":"userAddress","type":"address"},{"indexed":false,"name":"amount","type":"uint256"}],"name":"UserTokenBurnLog","type":"event"},
+{"anonymous":false,"inputs":[{"indexed":false,"name":"userAddress","type":"address"},{"indexed":false,"name":"name","type":"string"}],"name":"UserNameUpdateLog","type":"event"},
+{"anonymous":false,"inputs":[{"indexed":false,"name":"userAddress","type":"address"},{"indexed":false,"name":"icon","type":"string"}],"name":"UserIconUpdateLog","type":"event"}
+]`
+
+const userABIWrongTypes = `[
+{"constant":false,"inputs":[{"name":"timestamp","type":"uint256"},{"name":"signature","type":"bytes"},{"name":"user","type":"address"}],"name":"burn","outputs":[],"payable":false,"type":"function"},
+{"constant":false,"inputs":[{"name":"name","type":"string"},{"name":"signature","type":"bytes"},{"name":"user","type":"address"}],"name":"updateUserName","outputs":[],"payable":false,"type":"function"},
+{"constant":false,"inputs":[{"name":"icon","type":"string"},{"name":"signature","type":"bytes"},{"name":"user","type":"address"}],"name":"updateUserIcon","outputs":[],"payable":false,"type":"function"},
+{"anonymous":false,"inputs":[{"indexed":false,"name":"userAddress","type":"address"},{"indexed":false,"name":"amount","type":"uint256"}],"name":"UserTokenBurnLog","type":"event"},
+{"anonymous":false,"inputs":[{"indexed":false,"name":"userAddress","type":"address"},{"indexed":false,"name":"name","type":"string"}],"name":"UserNameUpdateLog","type":"event"},
+{"anonymous":false,"inputs":[{"indexed":false,"name":"userAddress","type":"address"},{"indexed":false,"name":"icon","type":"string"}],"name":"UserIconUpdateLog","type":"event"}
+]`
+
+const userABIMissingEvent = `[
+{"constant":false,"inputs":[{"name":"timestamp","type":"string"},{"name":"signature","type":"bytes"},{"name":"user","type":"address"}],"name":"burn","outputs":[],"payable":false,"type":"function"},
+{"constant":false,"inputs":[{"name":"name","type":"string"},{"name":"signature","type":"bytes"},{"name":"user","type":"address"}],"name":"updateUserName","outputs":[],"payable":false,"type":"function"},
+{"constant":false,"inputs":[{"name":"icon","type":"string"},{"name":"signature","type":"bytes"},{"name":"user","type":"address"}],"name":"updateUserIcon","outputs":[],"payable":false,"type":"function"}
+]`
+
+func TestValidateABI(t *testing.T) {
+
+	address := "0x15549387b1fa2a2cd050be68df040405caa1937e"
+
+	contract, err := contracts.NewContract(address, userABI)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, contracts.ValidateABI("user", contract), nil)
+
+	contract, err = contracts.NewContract(address, userABIWrongTypes)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, contracts.ValidateABI("user", contract) != nil, true)
+
+	contract, err = contracts.NewContract(address, userABIMissingEvent)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, contracts.ValidateABI("user", contract) != nil, true)
+}
@@ -0,0 +1,183 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/primasio/primas-node/config"
+)
+
+// Bindgen reads the contracts configuration itself rather than through the
+// contracts package, which would not build while its bindings are being
+// regenerated.
+
+type artifact struct {
+	ABI json.RawMessage `json:"abi"`
+}
+
+// loadABIs returns the ABI of the active deployment of every configured
+// contract, the one with the highest active_from_block.
+func loadABIs() (map[string]abi.ABI, error) {
+
+	abis := make(map[string]abi.ABI)
+
+	for name, item := range config.GetConfig().GetStringMap("contracts") {
+
+		itemMap, ok := toStringMap(item)
+
+		if !ok {
+			return nil, errors.New("contract " + name + " is invalid")
+		}
+
+		active, err := activeDeployment(name, itemMap)
+
+		if err != nil {
+			return nil, err
+		}
+
+		abiJson, err := loadABI(name, active)
+
+		if err != nil {
+			return nil, err
+		}
+
+		parsed, err := abi.JSON(strings.NewReader(abiJson))
+
+		if err != nil {
+			return nil, errors.New("contract " + name + " abi is invalid: " + err.Error())
+		}
+
+		abis[name] = parsed
+	}
+
+	return abis, nil
+}
+
+func activeDeployment(name string, itemMap map[string]interface{}) (map[string]interface{}, error) {
+
+	list, ok := itemMap["deployments"]
+
+	if !ok {
+		return itemMap, nil
+	}
+
+	items, ok := list.([]interface{})
+
+	if !ok || len(items) == 0 {
+		return nil, errors.New("contract " + name + " deployments are invalid")
+	}
+
+	var active map[string]interface{}
+	var activeFrom uint64
+
+	for _, item := range items {
+
+		deployment, ok := toStringMap(item)
+
+		if !ok {
+			return nil, errors.New("contract " + name + " deployments are invalid")
+		}
+
+		var from uint64
+
+		if value := deployment["active_from_block"]; value != nil {
+
+			parsed, err := strconv.ParseUint(fmt.Sprint(value), 10, 64)
+
+			if err != nil {
+				return nil, errors.New("contract " + name + " active_from_block is invalid")
+			}
+
+			from = parsed
+		}
+
+		if active == nil || from > activeFrom {
+			active = deployment
+			activeFrom = from
+		}
+	}
+
+	return active, nil
+}
+
+func loadABI(name string, itemMap map[string]interface{}) (string, error) {
+
+	if inline, ok := itemMap["abi"].(string); ok && inline != "" {
+		return inline, nil
+	}
+
+	if abiFile, ok := itemMap["abi_file"].(string); ok && abiFile != "" {
+
+		content, err := ioutil.ReadFile(config.ResolvePath(abiFile))
+
+		if err != nil {
+			return "", err
+		}
+
+		return string(content), nil
+	}
+
+	if artifactFile, ok := itemMap["artifact"].(string); ok && artifactFile != "" {
+
+		content, err := ioutil.ReadFile(config.ResolvePath(artifactFile))
+
+		if err != nil {
+			return "", err
+		}
+
+		a := &artifact{}
+
+		if err := json.Unmarshal(content, a); err != nil || len(a.ABI) == 0 {
+			return "", errors.New("contract " + name + " artifact has no abi")
+		}
+
+		return string(a.ABI), nil
+	}
+
+	return "", errors.New("contract " + name + " has no abi")
+}
+
+func toStringMap(item interface{}) (map[string]interface{}, bool) {
+
+	switch m := item.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{})
+
+		for k, v := range m {
+			key, ok := k.(string)
+
+			if !ok {
+				return nil, false
+			}
+
+			result[strings.ToLower(key)] = v
+		}
+
+		return result, true
+	}
+
+	return nil, false
+}
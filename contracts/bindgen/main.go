@@ -0,0 +1,327 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Bindgen generates typed Go bindings for the contracts configured for an
+// environment. It is run through go generate in the contracts package:
+//
+//	go generate github.com/primasio/primas-node/contracts
+//
+// Every transaction method of the active deployment gets a typed method
+// calling Contract.Execute, every constant method one calling Contract.Call,
+// and every event gets a struct and a decoder.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/primasio/primas-node/config"
+)
+
+type binding struct {
+	Name     string
+	Contract string
+	Methods  []method
+	Calls    []method
+	Events   []event
+}
+
+type param struct {
+	Name string
+	Type string
+}
+
+type method struct {
+	Name   string
+	GoName string
+	Params []param
+	Result string
+}
+
+type field struct {
+	Name   string
+	Type   string
+	Decode string
+}
+
+// Events without indexed inputs are decoded by the ABI, either as a
+// "tuple" into the event struct or as a "single" value into its only
+// field. The others are decoded "word" by word from topics and data.
+type event struct {
+	Name       string
+	Mode       string
+	Fields     []field
+	TopicCount int
+	DataSize   int
+}
+
+var goTypes = map[string]string{
+	"address":   "common.Address",
+	"address[]": "[]common.Address",
+	"bool":      "bool",
+	"bytes":     "[]byte",
+	"bytes32":   "[32]byte",
+	"string":    "string",
+	"uint8":     "uint8",
+	"uint16":    "uint16",
+	"uint32":    "uint32",
+	"uint64":    "uint64",
+	"uint256":   "*big.Int",
+	"uint256[]": "[]*big.Int",
+	"int256":    "*big.Int",
+}
+
+// Static types that can be read from a single 32 byte word
+var wordTypes = map[string]field{
+	"address": {Type: "common.Address", Decode: "common.BytesToAddress(%s)"},
+	"bool":    {Type: "bool", Decode: "new(big.Int).SetBytes(%s).Sign() != 0"},
+	"bytes32": {Type: "common.Hash", Decode: "common.BytesToHash(%s)"},
+	"uint256": {Type: "*big.Int", Decode: "new(big.Int).SetBytes(%s)"},
+}
+
+var goKeywords = map[string]bool{
+	"break": true, "case": true, "chan": true, "const": true, "continue": true,
+	"default": true, "defer": true, "else": true, "fallthrough": true, "for": true,
+	"func": true, "go": true, "goto": true, "if": true, "import": true,
+	"interface": true, "map": true, "package": true, "range": true, "return": true,
+	"select": true, "struct": true, "switch": true, "type": true, "var": true,
+}
+
+func main() {
+
+	environment := flag.String("e", "development", "")
+	configPath := flag.String("c", "../config/", "")
+	output := flag.String("o", "bindings.go", "")
+
+	flag.Parse()
+
+	config.Init(*environment, configPath)
+
+	abis, err := loadABIs()
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var bindings []binding
+
+	for name, contractABI := range abis {
+
+		b, err := newBinding(name, contractABI)
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		bindings = append(bindings, b)
+	}
+
+	sort.Slice(bindings, func(i, j int) bool {
+		return bindings[i].Name < bindings[j].Name
+	})
+
+	code := new(bytes.Buffer)
+
+	tmpl := template.Must(template.New("bindings").Parse(bindingsTemplate))
+
+	if err := tmpl.Execute(code, bindings); err != nil {
+		log.Fatal(err)
+	}
+
+	formatted, err := format.Source(code.Bytes())
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(*output, formatted, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func newBinding(name string, contractABI abi.ABI) (binding, error) {
+
+	b := binding{Name: exportedName(name), Contract: name}
+
+	for _, m := range contractABI.Methods {
+
+		bm := method{Name: m.Name, GoName: exportedName(m.Name)}
+
+		for i, input := range m.Inputs {
+
+			t, ok := goTypes[input.Type.String()]
+
+			if !ok {
+				return b, errors.New("unsupported type " + input.Type.String() + " in " + name + "." + m.Name)
+			}
+
+			bm.Params = append(bm.Params, param{Name: paramName(input.Name, i), Type: t})
+		}
+
+		// Constant methods are not sent as transactions
+		if !m.Const {
+			b.Methods = append(b.Methods, bm)
+			continue
+		}
+
+		if len(m.Outputs) != 1 {
+			return b, errors.New("constant method " + name + "." + m.Name + " must have a single output")
+		}
+
+		t, ok := goTypes[m.Outputs[0].Type.String()]
+
+		if !ok {
+			return b, errors.New("unsupported type " + m.Outputs[0].Type.String() + " in " + name + "." + m.Name)
+		}
+
+		bm.Result = t
+
+		b.Calls = append(b.Calls, bm)
+	}
+
+	sort.Slice(b.Methods, func(i, j int) bool {
+		return b.Methods[i].Name < b.Methods[j].Name
+	})
+
+	sort.Slice(b.Calls, func(i, j int) bool {
+		return b.Calls[i].Name < b.Calls[j].Name
+	})
+
+	for _, e := range contractABI.Events {
+
+		be, err := newEvent(name, e)
+
+		if err != nil {
+			return b, err
+		}
+
+		b.Events = append(b.Events, be)
+	}
+
+	sort.Slice(b.Events, func(i, j int) bool {
+		return b.Events[i].Name < b.Events[j].Name
+	})
+
+	return b, nil
+}
+
+func newEvent(contractName string, e abi.Event) (event, error) {
+
+	be := event{Name: e.Name, Mode: "tuple"}
+
+	if len(e.Inputs) == 1 {
+		be.Mode = "single"
+	}
+
+	for _, input := range e.Inputs {
+
+		// The ABI can only match struct fields to inputs named with a letter
+		if input.Indexed || input.Name == "" || !unicode.IsLetter(rune(input.Name[0])) {
+			be.Mode = "word"
+		}
+	}
+
+	if be.Mode != "word" {
+
+		for _, input := range e.Inputs {
+
+			t, ok := goTypes[input.Type.String()]
+
+			if !ok {
+				return be, errors.New("unsupported type " + input.Type.String() + " in " + contractName + "." + e.Name)
+			}
+
+			be.Fields = append(be.Fields, field{Name: exportedName(input.Name), Type: t})
+		}
+
+		return be, nil
+	}
+
+	be.TopicCount = 1
+
+	for i, input := range e.Inputs {
+
+		name := exportedName(input.Name)
+
+		if name == "" {
+			name = fmt.Sprintf("Arg%d", i)
+		}
+
+		var word string
+		var f field
+		var ok bool
+
+		if input.Indexed {
+
+			// Indexed dynamic values are only available as their hash
+			if input.Type.String() == "bytes" || input.Type.String() == "string" {
+				f, ok = wordTypes["bytes32"]
+			} else {
+				f, ok = wordTypes[input.Type.String()]
+			}
+
+			word = fmt.Sprintf("eventLog.Topics[%d].Bytes()", be.TopicCount)
+			be.TopicCount = be.TopicCount + 1
+		} else {
+
+			f, ok = wordTypes[input.Type.String()]
+
+			word = fmt.Sprintf("eventLog.Data[%d:%d]", be.DataSize, be.DataSize+32)
+			be.DataSize = be.DataSize + 32
+		}
+
+		if !ok {
+			return be, errors.New("unsupported type " + input.Type.String() + " in " + contractName + "." + e.Name)
+		}
+
+		be.Fields = append(be.Fields, field{Name: name, Type: f.Type, Decode: fmt.Sprintf(f.Decode, word)})
+	}
+
+	return be, nil
+}
+
+// exportedName turns an ABI name such as "_value" into "Value".
+func exportedName(name string) string {
+
+	name = strings.TrimLeft(name, "_")
+
+	if name == "" {
+		return ""
+	}
+
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+func paramName(name string, index int) string {
+
+	name = strings.TrimLeft(name, "_")
+
+	if name == "" || goKeywords[name] {
+		return fmt.Sprintf("arg%d", index)
+	}
+
+	return name
+}
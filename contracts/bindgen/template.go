@@ -0,0 +1,87 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+const bindingsTemplate = `// Code generated by bindgen. DO NOT EDIT.
+
+package contracts
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = errors.New
+	_ = big.NewInt
+	_ = common.Big1
+	_ = types.BloomLookup
+)
+{{range $b := .}}
+// {{$b.Name}}Binding provides typed access to the {{$b.Contract}} contract.
+type {{$b.Name}}Binding struct {
+	Contract *Contract
+}
+
+func New{{$b.Name}}Binding(contract *Contract) *{{$b.Name}}Binding {
+	return &{{$b.Name}}Binding{Contract: contract}
+}
+{{range $m := $b.Methods}}
+// {{$m.GoName}} sends the {{$m.Name}} transaction and returns its hash.
+func (binding *{{$b.Name}}Binding) {{$m.GoName}}({{range $i, $p := $m.Params}}{{if $i}}, {{end}}{{$p.Name}} {{$p.Type}}{{end}}) (string, error) {
+	return binding.Contract.Execute("{{$m.Name}}"{{range $m.Params}}, {{.Name}}{{end}})
+}
+{{end}}{{range $m := $b.Calls}}
+// {{$m.GoName}} calls the constant {{$m.Name}} method at blockNumber, or at the latest block when it is nil.
+func (binding *{{$b.Name}}Binding) {{$m.GoName}}(blockNumber *big.Int{{range $m.Params}}, {{.Name}} {{.Type}}{{end}}) ({{$m.Result}}, error) {
+	var result {{$m.Result}}
+
+	err := binding.Contract.Call(blockNumber, &result, "{{$m.Name}}"{{range $m.Params}}, {{.Name}}{{end}})
+
+	return result, err
+}
+{{end}}{{range $e := $b.Events}}
+// {{$b.Name}}{{$e.Name}} is the {{$e.Name}} event of the {{$b.Contract}} contract.
+type {{$b.Name}}{{$e.Name}} struct {
+{{range $e.Fields}}	{{.Name}} {{.Type}}
+{{end}}}
+
+// Unpack{{$e.Name}} decodes the {{$e.Name}} event log.
+func (binding *{{$b.Name}}Binding) Unpack{{$e.Name}}(eventLog *types.Log) (*{{$b.Name}}{{$e.Name}}, error) {
+	event := &{{$b.Name}}{{$e.Name}}{}
+{{if eq $e.Mode "tuple"}}
+	if err := binding.Contract.UnpackEvent(event, "{{$e.Name}}", eventLog); err != nil {
+		return nil, err
+	}
+{{else if eq $e.Mode "single"}}
+	if err := binding.Contract.UnpackEvent(&event.{{(index $e.Fields 0).Name}}, "{{$e.Name}}", eventLog); err != nil {
+		return nil, err
+	}
+{{else}}
+	if len(eventLog.Topics) != {{$e.TopicCount}} || len(eventLog.Data) != {{$e.DataSize}} {
+		return nil, errors.New("invalid {{$e.Name}} event log")
+	}
+{{range $e.Fields}}
+	event.{{.Name}} = {{.Decode}}{{end}}
+{{end}}
+	return event, nil
+}
+{{end}}{{end}}`
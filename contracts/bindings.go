@@ -0,0 +1,622 @@
+// Code generated by bindgen. DO NOT EDIT.
+
+package contracts
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = errors.New
+	_ = big.NewInt
+	_ = common.Big1
+	_ = types.BloomLookup
+)
+
+// ContentBinding provides typed access to the content contract.
+type ContentBinding struct {
+	Contract *Contract
+}
+
+func NewContentBinding(contract *Contract) *ContentBinding {
+	return &ContentBinding{Contract: contract}
+}
+
+// Comment sends the comment transaction and returns its hash.
+func (binding *ContentBinding) Comment(articleDNA []byte, groupDNA []byte, contentHash []byte, signature []byte, userAddress common.Address) (string, error) {
+	return binding.Contract.Execute("comment", articleDNA, groupDNA, contentHash, signature, userAddress)
+}
+
+// Like sends the like transaction and returns its hash.
+func (binding *ContentBinding) Like(articleDNA []byte, groupDNA []byte, signature []byte, userAddress common.Address) (string, error) {
+	return binding.Contract.Execute("like", articleDNA, groupDNA, signature, userAddress)
+}
+
+// Publish sends the publish transaction and returns its hash.
+func (binding *ContentBinding) Publish(title []byte, contentHash []byte, license []byte, extras []byte, blockHash []byte, signature []byte, DNA []byte, userAddress common.Address) (string, error) {
+	return binding.Contract.Execute("publish", title, contentHash, license, extras, blockHash, signature, DNA, userAddress)
+}
+
+// Share sends the share transaction and returns its hash.
+func (binding *ContentBinding) Share(articleDNA []byte, groupsDNA []byte, signature []byte, userAddress common.Address) (string, error) {
+	return binding.Contract.Execute("share", articleDNA, groupsDNA, signature, userAddress)
+}
+
+// GroupBinding provides typed access to the group contract.
+type GroupBinding struct {
+	Contract *Contract
+}
+
+func NewGroupBinding(contract *Contract) *GroupBinding {
+	return &GroupBinding{Contract: contract}
+}
+
+// AddMember sends the addMember transaction and returns its hash.
+func (binding *GroupBinding) AddMember(DNA []byte, signature []byte, memberAddress common.Address) (string, error) {
+	return binding.Contract.Execute("addMember", DNA, signature, memberAddress)
+}
+
+// Create sends the create transaction and returns its hash.
+func (binding *GroupBinding) Create(DNA []byte, title []byte, description []byte, signature []byte, userAddress common.Address) (string, error) {
+	return binding.Contract.Execute("create", DNA, title, description, signature, userAddress)
+}
+
+// RemoveMember sends the removeMember transaction and returns its hash.
+func (binding *GroupBinding) RemoveMember(DNA []byte, signature []byte, memberAddress common.Address) (string, error) {
+	return binding.Contract.Execute("removeMember", DNA, signature, memberAddress)
+}
+
+// RemoveMemberByOwner sends the removeMemberByOwner transaction and returns its hash.
+func (binding *GroupBinding) RemoveMemberByOwner(DNA []byte, memberAddress common.Address, signature []byte, ownerAddress common.Address) (string, error) {
+	return binding.Contract.Execute("removeMemberByOwner", DNA, memberAddress, signature, ownerAddress)
+}
+
+// GroupAddMemberLog is the AddMemberLog event of the group contract.
+type GroupAddMemberLog struct {
+	GroupDNA  []byte
+	Signature []byte
+}
+
+// UnpackAddMemberLog decodes the AddMemberLog event log.
+func (binding *GroupBinding) UnpackAddMemberLog(eventLog *types.Log) (*GroupAddMemberLog, error) {
+	event := &GroupAddMemberLog{}
+
+	if err := binding.Contract.UnpackEvent(event, "AddMemberLog", eventLog); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// GroupCreateLog is the CreateLog event of the group contract.
+type GroupCreateLog struct {
+	Title       []byte
+	Description []byte
+	Signature   []byte
+}
+
+// UnpackCreateLog decodes the CreateLog event log.
+func (binding *GroupBinding) UnpackCreateLog(eventLog *types.Log) (*GroupCreateLog, error) {
+	event := &GroupCreateLog{}
+
+	if err := binding.Contract.UnpackEvent(event, "CreateLog", eventLog); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// GroupRemoveMemberByOwnerLog is the RemoveMemberByOwnerLog event of the group contract.
+type GroupRemoveMemberByOwnerLog struct {
+	GroupDNA           []byte
+	GroupMemberAddress common.Address
+	Signature          []byte
+}
+
+// UnpackRemoveMemberByOwnerLog decodes the RemoveMemberByOwnerLog event log.
+func (binding *GroupBinding) UnpackRemoveMemberByOwnerLog(eventLog *types.Log) (*GroupRemoveMemberByOwnerLog, error) {
+	event := &GroupRemoveMemberByOwnerLog{}
+
+	if err := binding.Contract.UnpackEvent(event, "RemoveMemberByOwnerLog", eventLog); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// GroupRemoveMemberLog is the RemoveMemberLog event of the group contract.
+type GroupRemoveMemberLog struct {
+	GroupDNA  []byte
+	Signature []byte
+}
+
+// UnpackRemoveMemberLog decodes the RemoveMemberLog event log.
+func (binding *GroupBinding) UnpackRemoveMemberLog(eventLog *types.Log) (*GroupRemoveMemberLog, error) {
+	event := &GroupRemoveMemberLog{}
+
+	if err := binding.Contract.UnpackEvent(event, "RemoveMemberLog", eventLog); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// IncentivesBinding provides typed access to the incentives contract.
+type IncentivesBinding struct {
+	Contract *Contract
+}
+
+func NewIncentivesBinding(contract *Contract) *IncentivesBinding {
+	return &IncentivesBinding{Contract: contract}
+}
+
+// GrantIncentives sends the grantIncentives transaction and returns its hash.
+func (binding *IncentivesBinding) GrantIncentives(recipients []common.Address, amounts []*big.Int) (string, error) {
+	return binding.Contract.Execute("grantIncentives", recipients, amounts)
+}
+
+// UpdateNode sends the updateNode transaction and returns its hash.
+func (binding *IncentivesBinding) UpdateNode(nodeAddress common.Address) (string, error) {
+	return binding.Contract.Execute("updateNode", nodeAddress)
+}
+
+// MetadataBinding provides typed access to the metadata contract.
+type MetadataBinding struct {
+	Contract *Contract
+}
+
+func NewMetadataBinding(contract *Contract) *MetadataBinding {
+	return &MetadataBinding{Contract: contract}
+}
+
+// Comment sends the comment transaction and returns its hash.
+func (binding *MetadataBinding) Comment(articleDNA []byte, groupDNA []byte, contentHash []byte, signature []byte) (string, error) {
+	return binding.Contract.Execute("comment", articleDNA, groupDNA, contentHash, signature)
+}
+
+// Like sends the like transaction and returns its hash.
+func (binding *MetadataBinding) Like(articleDNA []byte, groupDNA []byte, signature []byte) (string, error) {
+	return binding.Contract.Execute("like", articleDNA, groupDNA, signature)
+}
+
+// Publish sends the publish transaction and returns its hash.
+func (binding *MetadataBinding) Publish(title []byte, contentHash []byte, license []byte, extras []byte, blockHash []byte, signature []byte, DNA []byte) (string, error) {
+	return binding.Contract.Execute("publish", title, contentHash, license, extras, blockHash, signature, DNA)
+}
+
+// Share sends the share transaction and returns its hash.
+func (binding *MetadataBinding) Share(articleDNA []byte, groupsDNA []byte, signature []byte) (string, error) {
+	return binding.Contract.Execute("share", articleDNA, groupsDNA, signature)
+}
+
+// UpdateContentContract sends the updateContentContract transaction and returns its hash.
+func (binding *MetadataBinding) UpdateContentContract(contentAddress common.Address) (string, error) {
+	return binding.Contract.Execute("updateContentContract", contentAddress)
+}
+
+// MetadataCommentLog is the CommentLog event of the metadata contract.
+type MetadataCommentLog struct {
+	ArticleDNA  []byte
+	GroupDNA    []byte
+	ContentHash []byte
+	Signature   []byte
+}
+
+// UnpackCommentLog decodes the CommentLog event log.
+func (binding *MetadataBinding) UnpackCommentLog(eventLog *types.Log) (*MetadataCommentLog, error) {
+	event := &MetadataCommentLog{}
+
+	if err := binding.Contract.UnpackEvent(event, "CommentLog", eventLog); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// MetadataLikeLog is the LikeLog event of the metadata contract.
+type MetadataLikeLog struct {
+	ArticleDNA []byte
+	GroupDNA   []byte
+	Signature  []byte
+}
+
+// UnpackLikeLog decodes the LikeLog event log.
+func (binding *MetadataBinding) UnpackLikeLog(eventLog *types.Log) (*MetadataLikeLog, error) {
+	event := &MetadataLikeLog{}
+
+	if err := binding.Contract.UnpackEvent(event, "LikeLog", eventLog); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// MetadataPublishLog is the PublishLog event of the metadata contract.
+type MetadataPublishLog struct {
+	Title       []byte
+	ContentHash []byte
+	License     []byte
+	Extras      []byte
+	BlockHash   []byte
+	Signature   []byte
+	DNA         []byte
+}
+
+// UnpackPublishLog decodes the PublishLog event log.
+func (binding *MetadataBinding) UnpackPublishLog(eventLog *types.Log) (*MetadataPublishLog, error) {
+	event := &MetadataPublishLog{}
+
+	if err := binding.Contract.UnpackEvent(event, "PublishLog", eventLog); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// MetadataShareLog is the ShareLog event of the metadata contract.
+type MetadataShareLog struct {
+	ArticleDNA []byte
+	GroupsDNA  []byte
+	Signature  []byte
+}
+
+// UnpackShareLog decodes the ShareLog event log.
+func (binding *MetadataBinding) UnpackShareLog(eventLog *types.Log) (*MetadataShareLog, error) {
+	event := &MetadataShareLog{}
+
+	if err := binding.Contract.UnpackEvent(event, "ShareLog", eventLog); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// TokenBinding provides typed access to the token contract.
+type TokenBinding struct {
+	Contract *Contract
+}
+
+func NewTokenBinding(contract *Contract) *TokenBinding {
+	return &TokenBinding{Contract: contract}
+}
+
+// Approve sends the approve transaction and returns its hash.
+func (binding *TokenBinding) Approve(spender common.Address, value *big.Int) (string, error) {
+	return binding.Contract.Execute("approve", spender, value)
+}
+
+// Burns sends the burns transaction and returns its hash.
+func (binding *TokenBinding) Burns(from common.Address, value *big.Int) (string, error) {
+	return binding.Contract.Execute("burns", from, value)
+}
+
+// DeletePermissionContract sends the deletePermissionContract transaction and returns its hash.
+func (binding *TokenBinding) DeletePermissionContract(contract common.Address) (string, error) {
+	return binding.Contract.Execute("deletePermissionContract", contract)
+}
+
+// DeletePermissionNode sends the deletePermissionNode transaction and returns its hash.
+func (binding *TokenBinding) DeletePermissionNode(operator common.Address) (string, error) {
+	return binding.Contract.Execute("deletePermissionNode", operator)
+}
+
+// IncentivesIn sends the incentivesIn transaction and returns its hash.
+func (binding *TokenBinding) IncentivesIn(users []common.Address, values []*big.Int) (string, error) {
+	return binding.Contract.Execute("incentivesIn", users, values)
+}
+
+// IncentivesOut sends the incentivesOut transaction and returns its hash.
+func (binding *TokenBinding) IncentivesOut(users []common.Address, values []*big.Int) (string, error) {
+	return binding.Contract.Execute("incentivesOut", users, values)
+}
+
+// Inflate sends the inflate transaction and returns its hash.
+func (binding *TokenBinding) Inflate() (string, error) {
+	return binding.Contract.Execute("inflate")
+}
+
+// TokenStatusLock sends the tokenStatusLock transaction and returns its hash.
+func (binding *TokenBinding) TokenStatusLock(userAddress common.Address, dna []byte, amount *big.Int) (string, error) {
+	return binding.Contract.Execute("tokenStatusLock", userAddress, dna, amount)
+}
+
+// TokenStatusUnlock sends the tokenStatusUnlock transaction and returns its hash.
+func (binding *TokenBinding) TokenStatusUnlock(userAddress common.Address, dna []byte) (string, error) {
+	return binding.Contract.Execute("tokenStatusUnlock", userAddress, dna)
+}
+
+// TokenTimeLock sends the tokenTimeLock transaction and returns its hash.
+func (binding *TokenBinding) TokenTimeLock(userAddress common.Address, dna []byte, value *big.Int, releaseTime *big.Int) (string, error) {
+	return binding.Contract.Execute("tokenTimeLock", userAddress, dna, value, releaseTime)
+}
+
+// TokenTimeUnlock sends the tokenTimeUnlock transaction and returns its hash.
+func (binding *TokenBinding) TokenTimeUnlock(userAddress common.Address, dna []byte) (string, error) {
+	return binding.Contract.Execute("tokenTimeUnlock", userAddress, dna)
+}
+
+// Transfer sends the transfer transaction and returns its hash.
+func (binding *TokenBinding) Transfer(to common.Address, value *big.Int) (string, error) {
+	return binding.Contract.Execute("transfer", to, value)
+}
+
+// TransferFrom sends the transferFrom transaction and returns its hash.
+func (binding *TokenBinding) TransferFrom(from common.Address, to common.Address, value *big.Int) (string, error) {
+	return binding.Contract.Execute("transferFrom", from, to, value)
+}
+
+// TransferOwnership sends the transferOwnership transaction and returns its hash.
+func (binding *TokenBinding) TransferOwnership(newOwner common.Address) (string, error) {
+	return binding.Contract.Execute("transferOwnership", newOwner)
+}
+
+// UpdatePermissionContract sends the updatePermissionContract transaction and returns its hash.
+func (binding *TokenBinding) UpdatePermissionContract(contract common.Address) (string, error) {
+	return binding.Contract.Execute("updatePermissionContract", contract)
+}
+
+// UpdatePermissionNode sends the updatePermissionNode transaction and returns its hash.
+func (binding *TokenBinding) UpdatePermissionNode(operator common.Address) (string, error) {
+	return binding.Contract.Execute("updatePermissionNode", operator)
+}
+
+// Allowance calls the constant allowance method at blockNumber, or at the latest block when it is nil.
+func (binding *TokenBinding) Allowance(blockNumber *big.Int, owner common.Address, spender common.Address) (*big.Int, error) {
+	var result *big.Int
+
+	err := binding.Contract.Call(blockNumber, &result, "allowance", owner, spender)
+
+	return result, err
+}
+
+// BalanceOf calls the constant balanceOf method at blockNumber, or at the latest block when it is nil.
+func (binding *TokenBinding) BalanceOf(blockNumber *big.Int, owner common.Address) (*big.Int, error) {
+	var result *big.Int
+
+	err := binding.Contract.Call(blockNumber, &result, "balanceOf", owner)
+
+	return result, err
+}
+
+// Decimals calls the constant decimals method at blockNumber, or at the latest block when it is nil.
+func (binding *TokenBinding) Decimals(blockNumber *big.Int) (*big.Int, error) {
+	var result *big.Int
+
+	err := binding.Contract.Call(blockNumber, &result, "decimals")
+
+	return result, err
+}
+
+// GetIncentivesPool calls the constant getIncentivesPool method at blockNumber, or at the latest block when it is nil.
+func (binding *TokenBinding) GetIncentivesPool(blockNumber *big.Int) (*big.Int, error) {
+	var result *big.Int
+
+	err := binding.Contract.Call(blockNumber, &result, "getIncentivesPool")
+
+	return result, err
+}
+
+// Name calls the constant name method at blockNumber, or at the latest block when it is nil.
+func (binding *TokenBinding) Name(blockNumber *big.Int) (string, error) {
+	var result string
+
+	err := binding.Contract.Call(blockNumber, &result, "name")
+
+	return result, err
+}
+
+// Owner calls the constant owner method at blockNumber, or at the latest block when it is nil.
+func (binding *TokenBinding) Owner(blockNumber *big.Int) (common.Address, error) {
+	var result common.Address
+
+	err := binding.Contract.Call(blockNumber, &result, "owner")
+
+	return result, err
+}
+
+// Symbol calls the constant symbol method at blockNumber, or at the latest block when it is nil.
+func (binding *TokenBinding) Symbol(blockNumber *big.Int) (string, error) {
+	var result string
+
+	err := binding.Contract.Call(blockNumber, &result, "symbol")
+
+	return result, err
+}
+
+// TotalSupply calls the constant totalSupply method at blockNumber, or at the latest block when it is nil.
+func (binding *TokenBinding) TotalSupply(blockNumber *big.Int) (*big.Int, error) {
+	var result *big.Int
+
+	err := binding.Contract.Call(blockNumber, &result, "totalSupply")
+
+	return result, err
+}
+
+// Version calls the constant version method at blockNumber, or at the latest block when it is nil.
+func (binding *TokenBinding) Version(blockNumber *big.Int) (string, error) {
+	var result string
+
+	err := binding.Contract.Call(blockNumber, &result, "version")
+
+	return result, err
+}
+
+// TokenApproval is the Approval event of the token contract.
+type TokenApproval struct {
+	Owner   common.Address
+	Spender common.Address
+	Value   *big.Int
+}
+
+// UnpackApproval decodes the Approval event log.
+func (binding *TokenBinding) UnpackApproval(eventLog *types.Log) (*TokenApproval, error) {
+	event := &TokenApproval{}
+
+	if len(eventLog.Topics) != 3 || len(eventLog.Data) != 32 {
+		return nil, errors.New("invalid Approval event log")
+	}
+
+	event.Owner = common.BytesToAddress(eventLog.Topics[1].Bytes())
+	event.Spender = common.BytesToAddress(eventLog.Topics[2].Bytes())
+	event.Value = new(big.Int).SetBytes(eventLog.Data[0:32])
+
+	return event, nil
+}
+
+// TokenInflate is the Inflate event of the token contract.
+type TokenInflate struct {
+	IncentivesPoolValue *big.Int
+}
+
+// UnpackInflate decodes the Inflate event log.
+func (binding *TokenBinding) UnpackInflate(eventLog *types.Log) (*TokenInflate, error) {
+	event := &TokenInflate{}
+
+	if err := binding.Contract.UnpackEvent(&event.IncentivesPoolValue, "Inflate", eventLog); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// TokenLock is the Lock event of the token contract.
+type TokenLock struct {
+	UserAddress  common.Address
+	ResourceType *big.Int
+	ResourceDNA  []byte
+	Amount       *big.Int
+	Expire       *big.Int
+}
+
+// UnpackLock decodes the Lock event log.
+func (binding *TokenBinding) UnpackLock(eventLog *types.Log) (*TokenLock, error) {
+	event := &TokenLock{}
+
+	if err := binding.Contract.UnpackEvent(event, "Lock", eventLog); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// TokenOwnershipTransferred is the OwnershipTransferred event of the token contract.
+type TokenOwnershipTransferred struct {
+	PreviousOwner common.Address
+	NewOwner      common.Address
+}
+
+// UnpackOwnershipTransferred decodes the OwnershipTransferred event log.
+func (binding *TokenBinding) UnpackOwnershipTransferred(eventLog *types.Log) (*TokenOwnershipTransferred, error) {
+	event := &TokenOwnershipTransferred{}
+
+	if len(eventLog.Topics) != 3 || len(eventLog.Data) != 0 {
+		return nil, errors.New("invalid OwnershipTransferred event log")
+	}
+
+	event.PreviousOwner = common.BytesToAddress(eventLog.Topics[1].Bytes())
+	event.NewOwner = common.BytesToAddress(eventLog.Topics[2].Bytes())
+
+	return event, nil
+}
+
+// TokenTransfer is the Transfer event of the token contract.
+type TokenTransfer struct {
+	From  common.Address
+	To    common.Address
+	Value *big.Int
+}
+
+// UnpackTransfer decodes the Transfer event log.
+func (binding *TokenBinding) UnpackTransfer(eventLog *types.Log) (*TokenTransfer, error) {
+	event := &TokenTransfer{}
+
+	if len(eventLog.Topics) != 3 || len(eventLog.Data) != 32 {
+		return nil, errors.New("invalid Transfer event log")
+	}
+
+	event.From = common.BytesToAddress(eventLog.Topics[1].Bytes())
+	event.To = common.BytesToAddress(eventLog.Topics[2].Bytes())
+	event.Value = new(big.Int).SetBytes(eventLog.Data[0:32])
+
+	return event, nil
+}
+
+// UserBinding provides typed access to the user contract.
+type UserBinding struct {
+	Contract *Contract
+}
+
+func NewUserBinding(contract *Contract) *UserBinding {
+	return &UserBinding{Contract: contract}
+}
+
+// Burn sends the burn transaction and returns its hash.
+func (binding *UserBinding) Burn(timestamp string, signature []byte, user common.Address) (string, error) {
+	return binding.Contract.Execute("burn", timestamp, signature, user)
+}
+
+// UpdateUserIcon sends the updateUserIcon transaction and returns its hash.
+func (binding *UserBinding) UpdateUserIcon(icon string, signature []byte, user common.Address) (string, error) {
+	return binding.Contract.Execute("updateUserIcon", icon, signature, user)
+}
+
+// UpdateUserName sends the updateUserName transaction and returns its hash.
+func (binding *UserBinding) UpdateUserName(name string, signature []byte, user common.Address) (string, error) {
+	return binding.Contract.Execute("updateUserName", name, signature, user)
+}
+
+// UserUserIconUpdateLog is the UserIconUpdateLog event of the user contract.
+type UserUserIconUpdateLog struct {
+	UserAddress common.Address
+	Icon        string
+}
+
+// UnpackUserIconUpdateLog decodes the UserIconUpdateLog event log.
+func (binding *UserBinding) UnpackUserIconUpdateLog(eventLog *types.Log) (*UserUserIconUpdateLog, error) {
+	event := &UserUserIconUpdateLog{}
+
+	if err := binding.Contract.UnpackEvent(event, "UserIconUpdateLog", eventLog); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// UserUserNameUpdateLog is the UserNameUpdateLog event of the user contract.
+type UserUserNameUpdateLog struct {
+	UserAddress common.Address
+	Name        string
+}
+
+// UnpackUserNameUpdateLog decodes the UserNameUpdateLog event log.
+func (binding *UserBinding) UnpackUserNameUpdateLog(eventLog *types.Log) (*UserUserNameUpdateLog, error) {
+	event := &UserUserNameUpdateLog{}
+
+	if err := binding.Contract.UnpackEvent(event, "UserNameUpdateLog", eventLog); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// UserUserTokenBurnLog is the UserTokenBurnLog event of the user contract.
+type UserUserTokenBurnLog struct {
+	UserAddress common.Address
+	Amount      *big.Int
+}
+
+// UnpackUserTokenBurnLog decodes the UserTokenBurnLog event log.
+func (binding *UserBinding) UnpackUserTokenBurnLog(eventLog *types.Log) (*UserUserTokenBurnLog, error) {
+	event := &UserUserTokenBurnLog{}
+
+	if err := binding.Contract.UnpackEvent(event, "UserTokenBurnLog", eventLog); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
@@ -26,6 +26,7 @@ var contentContract *ContentContract = nil
 
 type ContentContract struct {
 	Contract *Contract
+	Binding *ContentBinding
 }
 
 type Content interface {
@@ -49,6 +50,8 @@ func GetContentContract() (*ContentContract, error) {
 			return nil, err
 		}
 
+		contract.Binding = NewContentBinding(contract.Contract)
+
 		contentContract = contract
 	}
 
@@ -65,8 +68,7 @@ func (contentContract *ContentContract) Publish (content Content) error {
 
 	address := common.HexToAddress(content.GetUserAddress())
 
-	txHash, err := contentContract.Contract.Execute(
-		"publish",
+	txHash, err := contentContract.Binding.Publish(
 		title,
 		contentHash,
 		license,
@@ -95,8 +97,7 @@ func (contentContract *ContentContract) Like (like *models.ArticleLike) error {
 
 	address := common.HexToAddress(like.GroupMemberAddress)
 
-	txHash, err := contentContract.Contract.Execute(
-		"like",
+	txHash, err := contentContract.Binding.Like(
 		[]byte(like.ArticleDNA),
 		[]byte(like.GroupDNA),
 		sigBytes,
@@ -121,8 +122,7 @@ func (contentContract *ContentContract) Comment (comment *models.ArticleComment)
 
 	address := common.HexToAddress(comment.GroupMemberAddress)
 
-	txHash, err := contentContract.Contract.Execute(
-		"comment",
+	txHash, err := contentContract.Binding.Comment(
 		[]byte(comment.ArticleDNA),
 		[]byte(comment.GroupDNA),
 		[]byte(comment.ContentHash),
@@ -150,8 +150,7 @@ func (contentContract *ContentContract) Share (share *models.ArticleShareBatch)
 
 	address := common.HexToAddress(share.GroupMemberAddress)
 
-	txHash, err := contentContract.Contract.Execute(
-		"share",
+	txHash, err := contentContract.Binding.Share(
 		[]byte(share.ArticleDNA),
 		[]byte(groupsDNA),
 		sigBytes,
@@ -14,11 +14,14 @@
  * limitations under the License.
  */
 
+//go:generate go run ./bindgen -e development -c ../config/ -o bindings.go
+
 package contracts
 
 import (
 	"strings"
 	"context"
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/primasio/primas-node/config"
 	"github.com/ethereum/go-ethereum/common"
@@ -29,14 +32,28 @@ import (
 	"time"
 	"errors"
 	"sync"
+	"sort"
 )
 
-type Contract struct {
+// Deployment is one on-chain instance of a logical contract. Events are
+// only accepted from a deployment between its active blocks, an
+// ActiveToBlock of 0 meaning it is still active.
+type Deployment struct {
 	Address common.Address
 	ABI abi.ABI
+	ActiveFromBlock uint64
+	ActiveToBlock uint64
 	eventNameHashMap map[string]string
 }
 
+// Contract is a logical contract. Address and ABI refer to the currently
+// active deployment, which is the one transactions are sent to.
+type Contract struct {
+	Address common.Address
+	ABI abi.ABI
+	Deployments []*Deployment
+}
+
 var contractsByName map[string]*Contract
 var currentNonce uint64
 var contractMutex *sync.Mutex
@@ -57,27 +74,31 @@ func InitContracts () error {
 			return errors.New("contract initialization failed")
 		}
 
-		address, ok := itemMap["address"].(string)
+		deployments, err := loadContractDeployments(name, itemMap)
 
-		if !ok {
-			return errors.New("contract address initialization failed")
+		if err != nil {
+			return err
 		}
 
-		abiJson, ok := itemMap["abi"].(string)
+		nContract, err := NewContractWithDeployments(deployments)
 
-		if !ok {
-			return errors.New("contract abi initialization failed")
+		if err != nil {
+			return err
 		}
 
-		nContract, err := NewContract(address, abiJson)
-
-		if err != nil {
+		if err := ValidateABI(name, nContract); err != nil {
 			return err
 		}
 
 		contractsByName[name] = nContract
 	}
 
+	for name := range abiRequirements {
+		if contractsByName[name] == nil {
+			return errors.New("contract " + name + " is not configured")
+		}
+	}
+
 	contractMutex = &sync.Mutex{}
 	currentNonce = 0
 
@@ -98,51 +119,184 @@ func GetAllContracts() map[string]*Contract {
 
 func NewContract(address, abi string) (*Contract, error) {
 
+	deployment, err := NewDeployment(address, abi, 0, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewContractWithDeployments([]*Deployment{deployment})
+}
+
+func NewContractWithDeployments(deployments []*Deployment) (*Contract, error) {
+
+	if len(deployments) == 0 {
+		return nil, errors.New("contract has no deployment")
+	}
+
+	contract := new(Contract)
+	contract.Deployments = deployments
+
+	sort.Slice(contract.Deployments, func(i, j int) bool {
+		return contract.Deployments[i].ActiveFromBlock < contract.Deployments[j].ActiveFromBlock
+	})
+
+	for i, deployment := range contract.Deployments {
+
+		isLast := i == len(contract.Deployments) - 1
+
+		if !isLast && deployment.ActiveToBlock == 0 {
+			return nil, errors.New("only the latest contract deployment can be left open")
+		}
+
+		if !isLast && deployment.ActiveToBlock >= contract.Deployments[i+1].ActiveFromBlock {
+			return nil, errors.New("contract deployments overlap: " + deployment.Address.Hex())
+		}
+	}
+
+	active := contract.GetActiveDeployment()
+
+	contract.Address = active.Address
+	contract.ABI = active.ABI
+
+	return contract, nil
+}
+
+func NewDeployment(address, abi string, activeFromBlock, activeToBlock uint64) (*Deployment, error) {
+
 	if address == "" || abi == "" {
 		return nil, errors.New("contract address and abi cannot be blank")
 	}
 
-	contract := new(Contract)
+	if activeToBlock != 0 && activeToBlock < activeFromBlock {
+		return nil, errors.New("contract deployment ends before it starts: " + address)
+	}
+
+	deployment := new(Deployment)
 
-	contract.Address = common.HexToAddress(address)
+	deployment.Address = common.HexToAddress(address)
+	deployment.ActiveFromBlock = activeFromBlock
+	deployment.ActiveToBlock = activeToBlock
 
-	err := contract.InitABI(abi)
+	err := deployment.InitABI(abi)
 
 	if err != nil {
 		return nil, err
 	}
 
-	contract.InitEventNameHashMap()
+	deployment.InitEventNameHashMap()
 
-	return contract, nil
+	return deployment, nil
 }
 
-func (contract *Contract) InitABI (ABIJson string) error {
+func (deployment *Deployment) InitABI (ABIJson string) error {
 	abiInstance, err := abi.JSON(strings.NewReader(ABIJson))
 
 	if err != nil {
 		return err
 	}
 
-	contract.ABI = abiInstance
+	deployment.ABI = abiInstance
 
 	return nil
 }
 
-func (contract *Contract) Execute (method string, args ...interface{}) (string, error) {
+func (deployment *Deployment) InitEventNameHashMap() {
 
-	c := config.GetConfig()
+	deployment.eventNameHashMap = make(map[string]string)
 
-	methodBytes, err := contract.ABI.Pack(method, args...)
+	for _, event := range deployment.ABI.Events {
+		deployment.eventNameHashMap[event.Id().Hex()] = event.Name
+	}
+}
+
+func (deployment *Deployment) IsActiveAt(blockNumber uint64) bool {
+	return blockNumber >= deployment.ActiveFromBlock &&
+		(deployment.ActiveToBlock == 0 || blockNumber <= deployment.ActiveToBlock)
+}
+
+// IsActiveBetween reports whether the deployment is active at any block
+// of the inclusive range.
+func (deployment *Deployment) IsActiveBetween(fromBlock, toBlock uint64) bool {
+	return toBlock >= deployment.ActiveFromBlock &&
+		(deployment.ActiveToBlock == 0 || fromBlock <= deployment.ActiveToBlock)
+}
+
+// GetActiveDeployment returns the deployment transactions are sent to,
+// which is the latest one.
+func (contract *Contract) GetActiveDeployment() *Deployment {
+	return contract.Deployments[len(contract.Deployments) - 1]
+}
+
+func (contract *Contract) GetDeploymentByAddress(address common.Address) (*Deployment, error) {
+
+	for _, deployment := range contract.Deployments {
+		if deployment.Address == address {
+			return deployment, nil
+		}
+	}
+
+	return nil, errors.New("contract deployment does not exist: " + address.Hex())
+}
+
+// SubmitGuard is checked by scheduled jobs before each transaction they
+// submit. It returns an error once the instance no longer runs the jobs.
+type SubmitGuard func() error
+
+// Check calls the guard, a nil guard allows every transaction.
+func (guard SubmitGuard) Check() error {
+
+	if guard == nil {
+		return nil
+	}
+
+	return guard()
+}
+
+func (contract *Contract) Execute (method string, args ...interface{}) (string, error) {
+
+	tx, err := contract.Submit(nil, nil, method, args...)
 
 	if err != nil {
 		return "", err
 	}
 
-	client, err := contract.GetEthClient()
+	return tx.Hash().String(), nil
+}
+
+// Submit sends a transaction calling method and returns it. The nonce and
+// gas price of the node account are used unless given, a transaction
+// with the nonce of a pending one replaces it if its gas price is higher.
+func (contract *Contract) Submit (nonce *uint64, gasPrice *big.Int, method string, args ...interface{}) (*types.Transaction, error) {
+
+	signedTx, err := contract.Sign(nonce, gasPrice, method, args...)
 
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	if err := contract.Send(signedTx); err != nil {
+		return nil, err
+	}
+
+	return signedTx, nil
+}
+
+// Sign builds and signs a transaction calling method without sending it,
+// so that its nonce and hash can be recorded first. The nonce of the node
+// account is taken unless given.
+func (contract *Contract) Sign (nonce *uint64, gasPrice *big.Int, method string, args ...interface{}) (*types.Transaction, error) {
+
+	c := config.GetConfig()
+
+	methodBytes, err := contract.ABI.Pack(method, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := contract.GetEthClient(); err != nil {
+		return nil, err
 	}
 
 	nodeAccount := account.GetNodeAccount()
@@ -150,50 +304,150 @@ func (contract *Contract) Execute (method string, args ...interface{}) (string,
 	duration, err2 := time.ParseDuration(c.GetString("eth_node.timeout"))
 
 	if err2 != nil {
-		return "", err2
+		return nil, err2
+	}
+
+	if gasPrice == nil {
+		gasPrice = big.NewInt(c.GetInt64("node_account.gas_price"))
 	}
 
 	contractMutex.Lock()
+	defer contractMutex.Unlock()
+
+	var txNonce uint64
 
-	ctx, _ := context.WithTimeout(context.Background(), duration)
+	if nonce != nil {
+		txNonce = *nonce
+	} else {
 
-	nonce, err := ethClient.NonceAt(ctx, nodeAccount.Address, nil)
+		ctx, _ := context.WithTimeout(context.Background(), duration)
 
-	if err != nil {
-		return "", err
-	}
+		accountNonce, err := ethClient.NonceAt(ctx, nodeAccount.Address, nil)
 
-	if nonce > currentNonce || currentNonce == 0 {
-		currentNonce = nonce
-	} else {
-		currentNonce = currentNonce + 1
+		if err != nil {
+			return nil, err
+		}
+
+		if accountNonce > currentNonce || currentNonce == 0 {
+			currentNonce = accountNonce
+		} else {
+			currentNonce = currentNonce + 1
+		}
+
+		txNonce = currentNonce
 	}
 
 	tx := types.NewTransaction(
-		currentNonce,
+		txNonce,
 		contract.Address,
 		big.NewInt(0),
 		big.NewInt(c.GetInt64("node_account.gas_limit")),
-		big.NewInt(c.GetInt64("node_account.gas_price")),
+		gasPrice,
 		methodBytes)
 
 	ks := account.GetNodeKeystore()
-	signedTx, err := ks.SignTx(*nodeAccount, tx, big.NewInt(c.GetInt64("eth_node.chain_id")))
+
+	return ks.SignTx(*nodeAccount, tx, big.NewInt(c.GetInt64("eth_node.chain_id")))
+}
+
+// Send broadcasts a transaction signed by Sign. An error does not mean the
+// node did not receive it.
+func (contract *Contract) Send (signedTx *types.Transaction) error {
+
+	client, err := contract.GetEthClient()
 
 	if err != nil {
-		contractMutex.Unlock()
-		return "", err
+		return err
 	}
 
-	ctx2, _ := context.WithTimeout(context.Background(), duration)
+	duration, err := time.ParseDuration(config.GetConfig().GetString("eth_node.timeout"))
 
-	if err := client.SendTransaction(ctx2, signedTx); err != nil {
-		contractMutex.Unlock()
-		return "", err
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	defer cancel()
+
+	return client.SendTransaction(ctx, signedTx)
+}
+
+// Call executes a constant method of the active deployment and decodes
+// its output into result. The state is read at blockNumber, or at the
+// latest block when blockNumber is nil.
+func (contract *Contract) Call (blockNumber *big.Int, result interface{}, method string, args ...interface{}) error {
+
+	c := config.GetConfig()
+
+	input, err := contract.ABI.Pack(method, args...)
+
+	if err != nil {
+		return err
+	}
+
+	client, err := contract.GetEthClient()
+
+	if err != nil {
+		return err
+	}
+
+	duration, err := time.ParseDuration(c.GetString("eth_node.timeout"))
+
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	defer cancel()
+
+	msg := ethereum.CallMsg{ To: &contract.Address, Data: input }
+
+	output, err := client.CallContract(ctx, msg, blockNumber)
+
+	if err != nil {
+		return err
 	}
 
-	contractMutex.Unlock()
-	return signedTx.Hash().String(), nil
+	return contract.ABI.Unpack(result, method, output)
+}
+
+// Estimate dry-runs the transaction calling method from the node account
+// against the latest state. An error is returned when it would fail, so
+// the checks of the contract itself can be run before submitting.
+func (contract *Contract) Estimate (method string, args ...interface{}) error {
+
+	c := config.GetConfig()
+
+	input, err := contract.ABI.Pack(method, args...)
+
+	if err != nil {
+		return err
+	}
+
+	client, err := contract.GetEthClient()
+
+	if err != nil {
+		return err
+	}
+
+	duration, err := time.ParseDuration(c.GetString("eth_node.timeout"))
+
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	defer cancel()
+
+	msg := ethereum.CallMsg{
+		From: account.GetNodeAccount().Address,
+		To: &contract.Address,
+		Gas: big.NewInt(c.GetInt64("node_account.gas_limit")),
+		Data: input }
+
+	_, err = client.EstimateGas(ctx, msg)
+
+	return err
 }
 
 var ethClient *ethclient.Client
@@ -214,19 +468,36 @@ func (contract *Contract) GetEthClient () (*ethclient.Client, error) {
 	return ethClient, nil
 }
 
-func (contract *Contract) GetEventNameByTopicHash(hash string) (string, error) {
-	if contract.eventNameHashMap[hash] == "" {
-		return "", errors.New("topic does not exist")
+// GetEventName returns the name of the event emitted in the log, using
+// the ABI of the deployment that emitted it.
+func (contract *Contract) GetEventName(eventLog *types.Log) (string, error) {
+
+	deployment, err := contract.GetDeploymentByAddress(eventLog.Address)
+
+	if err != nil {
+		return "", err
 	}
 
-	return contract.eventNameHashMap[hash], nil
+	return deployment.GetEventNameByTopicHash(eventLog.Topics[0].Hex())
 }
 
-func (contract *Contract) InitEventNameHashMap() {
+// UnpackEvent decodes the log data using the ABI of the deployment that
+// emitted it.
+func (contract *Contract) UnpackEvent(v interface{}, name string, eventLog *types.Log) error {
 
-	contract.eventNameHashMap = make(map[string]string)
+	deployment, err := contract.GetDeploymentByAddress(eventLog.Address)
 
-	for _, event := range contract.ABI.Events {
-		contract.eventNameHashMap[event.Id().Hex()] = event.Name
+	if err != nil {
+		return err
 	}
-}
\ No newline at end of file
+
+	return deployment.ABI.Unpack(v, name, eventLog.Data)
+}
+
+func (deployment *Deployment) GetEventNameByTopicHash(hash string) (string, error) {
+	if deployment.eventNameHashMap[hash] == "" {
+		return "", errors.New("topic does not exist")
+	}
+
+	return deployment.eventNameHashMap[hash], nil
+}
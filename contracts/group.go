@@ -32,6 +32,7 @@ var groupContract *GroupContract = nil
 
 type GroupContract struct {
 	Contract *Contract
+	Binding *GroupBinding
 }
 
 func GetGroupContract() (*GroupContract, error) {
@@ -47,6 +48,8 @@ func GetGroupContract() (*GroupContract, error) {
 			return nil, err
 		}
 
+		contract.Binding = NewGroupBinding(contract.Contract)
+
 		groupContract = contract
 	}
 
@@ -63,8 +66,7 @@ func (groupContract *GroupContract) Create(group *models.Group) error {
 
 	address := common.HexToAddress(group.UserAddress)
 
-	txHash, err := groupContract.Contract.Execute(
-		"create",
+	txHash, err := groupContract.Binding.Create(
 		[]byte(group.DNA),
 		[]byte(group.Title),
 		[]byte(group.Description),
@@ -89,8 +91,7 @@ func (groupContract *GroupContract) AddMember(member *models.GroupMember) error
 
 	address := common.HexToAddress(member.MemberAddress)
 
-	txHash, err := groupContract.Contract.Execute(
-		"addMember",
+	txHash, err := groupContract.Binding.AddMember(
 		[]byte(member.GroupDNA),
 		sigBytes,
 		address)
@@ -113,8 +114,7 @@ func (groupContract *GroupContract) RemoveMember(member *models.GroupMember) err
 
 	address := common.HexToAddress(member.MemberAddress)
 
-	txHash, err := groupContract.Contract.Execute(
-		"removeMember",
+	txHash, err := groupContract.Binding.RemoveMember(
 		[]byte(member.GroupDNA),
 		sigBytes,
 		address )
@@ -137,10 +137,9 @@ func (groupContract *GroupContract) RemoveMemberByOwner(member *models.GroupMemb
 
 	address := common.HexToAddress(ownerAddress)
 
-	txHash, err := groupContract.Contract.Execute(
-		"removeMemberByOwner",
+	txHash, err := groupContract.Binding.RemoveMemberByOwner(
 		[]byte(member.GroupDNA),
-		[]byte(member.MemberAddress),
+		common.HexToAddress(member.MemberAddress),
 		sigBytes,
 		address )
 
@@ -153,52 +152,29 @@ func (groupContract *GroupContract) RemoveMemberByOwner(member *models.GroupMemb
 	return nil
 }
 
-type CreateLogArgs struct {
-	Title                []byte
-	Description          []byte
-	Signature            []byte
-}
-
-type AddMemberLogArgs struct {
-	GroupDNA             []byte
-	Signature            []byte
-}
-
-type RemoveMemberLogArgs struct {
-	GroupDNA             []byte
-	Signature            []byte
-}
-
-type RemoveMemberByOwnerLogArgs struct {
-	GroupDNA             []byte
-	GroupMemberAddress   []byte
-	Signature            []byte
-}
-
 func (groupContract *GroupContract) HandleEvent(eventLog *types.Log, db *gorm.DB) error {
 
-	for _, topic := range eventLog.Topics {
+	// We only need event name topic
 
-		name, err := groupContract.Contract.GetEventNameByTopicHash(topic.Hex())
+	name, err := groupContract.Contract.GetEventName(eventLog)
 
-		if err != nil {
-			return err
-		}
+	if err != nil {
+		return err
+	}
 
-		log.Println("event triggered: " + name)
-
-		switch name {
-			case "CreateLog":
-				return groupContract.handleCreate(name, eventLog, db)
-			case "AddMemberLog":
-				return groupContract.handleAddMember(name, eventLog, db)
-			case "RemoveMemberLog":
-				return groupContract.handleRemoveMember(name, eventLog, db)
-			case "RemoveMemberByOwnerLog":
-				return groupContract.handleRemoveMemberByOwner(name, eventLog, db)
-			default:
-				return errors.New("unrecognized event: " + name)
-		}
+	log.Println("event triggered: " + name)
+
+	switch name {
+		case "CreateLog":
+			return groupContract.handleCreate(name, eventLog, db)
+		case "AddMemberLog":
+			return groupContract.handleAddMember(name, eventLog, db)
+		case "RemoveMemberLog":
+			return groupContract.handleRemoveMember(name, eventLog, db)
+		case "RemoveMemberByOwnerLog":
+			return groupContract.handleRemoveMemberByOwner(name, eventLog, db)
+		default:
+			return errors.New("unrecognized event: " + name)
 	}
 
 	return nil
@@ -206,9 +182,7 @@ func (groupContract *GroupContract) HandleEvent(eventLog *types.Log, db *gorm.DB
 
 func (groupContract *GroupContract) handleCreate(name string, eventLog *types.Log, db *gorm.DB) error {
 
-	args := &CreateLogArgs{}
-
-	err := groupContract.Contract.ABI.Unpack(args, name, eventLog.Data)
+	args, err := groupContract.Binding.UnpackCreateLog(eventLog)
 
 	if err != nil {
 		return err
@@ -222,7 +196,7 @@ func (groupContract *GroupContract) handleCreate(name string, eventLog *types.Lo
 	sigBase := group.GetSignatureBaseString()
 	signature := hex.EncodeToString(args.Signature)
 
-	if user, err := crypto.ExtractUserFromSignature(sigBase, signature); err == nil {
+	if user, err := verifyEventSignature(eventLog, sigBase, crypto.GroupMessage(group), signature, db); err == nil {
 		group.UserAddress = user.Address
 	} else {
 		return err
@@ -264,9 +238,7 @@ func (groupContract *GroupContract) handleCreate(name string, eventLog *types.Lo
 }
 
 func (groupContract *GroupContract) handleAddMember(name string, eventLog *types.Log, db *gorm.DB) error {
-	args := &AddMemberLogArgs{}
-
-	err := groupContract.Contract.ABI.Unpack(args, name, eventLog.Data)
+	args, err := groupContract.Binding.UnpackAddMemberLog(eventLog)
 
 	if err != nil {
 		return err
@@ -279,7 +251,7 @@ func (groupContract *GroupContract) handleAddMember(name string, eventLog *types
 	sigBase := groupMember.GetSignatureBaseString()
 	signature := hex.EncodeToString(args.Signature)
 
-	if user, err := crypto.ExtractUserFromSignature(sigBase, signature); err == nil {
+	if user, err := verifyEventSignature(eventLog, sigBase, crypto.JoinGroupMessage(groupMember), signature, db); err == nil {
 		groupMember.MemberAddress = user.Address
 	} else {
 		return err
@@ -313,9 +285,7 @@ func (groupContract *GroupContract) handleAddMember(name string, eventLog *types
 }
 
 func (groupContract *GroupContract) handleRemoveMember(name string, eventLog *types.Log, db *gorm.DB) error {
-	args := &RemoveMemberLogArgs{}
-
-	err := groupContract.Contract.ABI.Unpack(args, name, eventLog.Data)
+	args, err := groupContract.Binding.UnpackRemoveMemberLog(eventLog)
 
 	if err != nil {
 		return err
@@ -328,7 +298,7 @@ func (groupContract *GroupContract) handleRemoveMember(name string, eventLog *ty
 	sigBase := groupMember.GetSignatureBaseString()
 	signature := hex.EncodeToString(args.Signature)
 
-	if user, err := crypto.ExtractUserFromSignature(sigBase, signature); err == nil {
+	if user, err := verifyEventSignature(eventLog, sigBase, crypto.LeaveGroupMessage(groupMember), signature, db); err == nil {
 		groupMember.MemberAddress = user.Address
 	} else {
 		return err
@@ -358,9 +328,7 @@ func (groupContract *GroupContract) handleRemoveMember(name string, eventLog *ty
 }
 
 func (groupContract *GroupContract) handleRemoveMemberByOwner(name string, eventLog *types.Log, db *gorm.DB) error {
-	args := &RemoveMemberByOwnerLogArgs{}
-
-	err := groupContract.Contract.ABI.Unpack(args, name, eventLog.Data)
+	args, err := groupContract.Binding.UnpackRemoveMemberByOwnerLog(eventLog)
 
 	if err != nil {
 		return err
@@ -369,7 +337,7 @@ func (groupContract *GroupContract) handleRemoveMemberByOwner(name string, event
 	groupMember := &models.GroupMember{}
 
 	groupMember.GroupDNA = string(args.GroupDNA)
-	groupMember.MemberAddress = string(args.GroupMemberAddress)
+	groupMember.MemberAddress = args.GroupMemberAddress.Hex()
 
 	db.Where(groupMember).First(groupMember)
 
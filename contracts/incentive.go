@@ -18,14 +18,26 @@ package contracts
 
 import (
 	"math/big"
+	"github.com/primasio/primas-node/account"
 	"github.com/primasio/primas-node/models"
 	"github.com/jinzhu/gorm"
+	"github.com/primasio/primas-node/config"
+	"github.com/shopspring/decimal"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"context"
+	"log"
+	"sort"
+	"strconv"
+	"time"
 )
 
 var incentiveContract *IncentiveContract = nil
 
 type IncentiveContract struct {
 	Contract *Contract
+	Binding *IncentivesBinding
 }
 
 func GetIncentiveContract () (*IncentiveContract, error) {
@@ -40,90 +52,484 @@ func GetIncentiveContract () (*IncentiveContract, error) {
 			return nil, err
 		}
 
+		contract.Binding = NewIncentivesBinding(contract.Contract)
+
 		incentiveContract = contract
 	}
 
 	return incentiveContract, nil
 }
 
-func (incentiveContract *IncentiveContract) AssignIncentives (db *gorm.DB) error {
-	batchSize := 200
-	currentBatchOffset := 0
+// AssignIncentives grants the calculated incentives on chain, run by run.
+// Records are grouped into batches of at most incentives.batch_size
+// recipients, each batch being one grantIncentives transaction. Records
+// stay in the paying status until the receipt of their batch is checked.
+// It runs after the synchronization committed, on the scheduler instance
+// only, which the guard checks before each batch.
+func (incentiveContract *IncentiveContract) AssignIncentives (guard SubmitGuard, db *gorm.DB) error {
 
-	userIncentives := make(map[string]*big.Int)
+	c := config.GetConfig()
+
+	batchSize := c.GetInt("incentives.batch_size")
+
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+
+	catchUpBlocks := uint64(c.GetInt64("incentives.catch_up_blocks"))
+
+	var latestBlock uint64
+
+	if latest := models.GetState("LatestBlockNumber", db); latest != "" {
+		latestBlock, _ = strconv.ParseUint(latest, 10, 64)
+	}
+
+	var runs []models.IncentiveRun
+
+	in := db.Where("status = ?", models.IncentiveRunCalculated)
+	in = in.Where("id IN (SELECT run_id FROM incentives WHERE status = ?)", models.IncentivesCalculating)
+	in.Order("id asc").Find(&runs)
+
+	for _, run := range runs {
+
+		// Inflations older than incentives.catch_up_blocks were paid by the
+		// nodes following the chain at the time, a node catching up does
+		// not pay them again
+
+		if catchUpBlocks > 0 && latestBlock > run.BlockNumber + catchUpBlocks {
+
+			log.Println("incentive run #" + strconv.FormatUint(uint64(run.ID), 10) + " of inflation " + run.TxHash + " is historical, not paid")
+
+			in := db.Table("incentives").Where("run_id = ? AND status = ?", run.ID, models.IncentivesCalculating)
+			in.Updates(map[string]interface{}{"status": models.IncentivesPaid})
+
+			continue
+		}
+
+		if err := incentiveContract.assignRun(&run, batchSize, guard, db); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (incentiveContract *IncentiveContract) assignRun (run *models.IncentiveRun, batchSize int, guard SubmitGuard, db *gorm.DB) error {
+
+	// Nothing to pay for records without amount
+
+	in := db.Table("incentives").Where("run_id = ? AND status = ?", run.ID, models.IncentivesCalculating).Where("amount = 0")
+	in.Updates(map[string]interface{}{"status": models.IncentivesPaid})
 
 	for {
-		var incs [] models.Incentive
+		var recipients []string
 
-		in := db.Table("incentives").Where("status = ?", models.IncentivesCalculating)
-		in = in.Order("created_at asc").Offset(currentBatchOffset).Limit(batchSize)
-		in.Find(&incs)
+		// Records of a created batch are no longer calculating, so the
+		// first page always holds the next recipients
 
-		if len(incs) == 0 {
+		in := db.Table("incentives").Where("run_id = ? AND status = ?", run.ID, models.IncentivesCalculating)
+		in = in.Order("user_address asc").Limit(batchSize)
+		in.Pluck("DISTINCT user_address", &recipients)
+
+		if len(recipients) == 0 {
 			break
 		}
 
+		if err := guard.Check(); err != nil {
+			return err
+		}
+
+		// The batch is created with its records before it is submitted, a
+		// batch left failed is submitted again. The run row is locked as
+		// by Revert, so that a run is never reverted while being paid.
+
+		tx := db.Begin()
+
+		locked := &models.IncentiveRun{}
+
+		tx.Set("gorm:query_option", "FOR UPDATE").Where("id = ?", run.ID).First(locked)
+
+		if locked.Status != models.IncentiveRunCalculated {
+			tx.Rollback()
+			return nil
+		}
+
+		var incs []models.Incentive
+
+		in = tx.Table("incentives").Where("run_id = ? AND status = ?", run.ID, models.IncentivesCalculating)
+		in = in.Where("user_address in (?)", recipients)
+		in.Find(&incs)
+
+		batch := &models.IncentiveBatch{}
+		batch.CreatedAt = uint(time.Now().Unix())
+		batch.UpdatedAt = batch.CreatedAt
+		batch.RunID = run.ID
+		batch.Status = models.IncentiveBatchFailed
+		batch.Attempts = 0
+		batch.TotalAmount = decimal.Zero
+		batch.GasPrice = decimal.Zero
+
+		var ids []uint
+
 		for _, incentive := range incs {
+			ids = append(ids, incentive.ID)
+			batch.TotalAmount = batch.TotalAmount.Add(incentive.Amount)
+		}
 
-			userAddress := incentive.UserAddress
-			amount := &big.Int{}
-			amount.Set(incentive.Amount.Coefficient())
+		batch.SetIncentiveIDs(ids)
+		batch.RecipientCount = uint(len(recipients))
 
-			if userIncentives[userAddress] != nil {
-				userIncentives[userAddress].Add(userIncentives[userAddress], amount)
-			} else {
-				userIncentives[userAddress] = amount
+		tx.Save(batch)
+
+		in = tx.Table("incentives").Where("id in (?)", ids)
+		in.Updates(map[string]interface{}{"status": models.IncentivesPaying, "batch_id": batch.ID})
+
+		if err := tx.Commit().Error; err != nil {
+			return err
+		}
+
+		if err := incentiveContract.submitBatch(batch, db); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// submitBatch sends the grantIncentives transaction of the batch with a
+// new nonce. A failed batch has nothing left that can be mined. The nonce
+// and hash are saved before the transaction is sent, a send error leaves
+// the batch submitted since the node may have broadcast it, it is then
+// replaced with the same nonce once not mined in time.
+func (incentiveContract *IncentiveContract) submitBatch(batch *models.IncentiveBatch, db *gorm.DB) error {
+
+	batch.Attempts = batch.Attempts + 1
+	batch.UpdatedAt = uint(time.Now().Unix())
+
+	tx, err := incentiveContract.signGrant(incentiveContract.getBatchIncentives(batch, db), nil, nil)
+
+	if err != nil {
+		batch.Status = models.IncentiveBatchFailed
+		batch.LastError = err.Error()
+		db.Save(batch)
+
+		return err
+	}
+
+	batch.TxHashes = ""
+	batch.AddTxHash(tx.Hash().String())
+	batch.Nonce = tx.Nonce()
+	batch.GasPrice = decimal.NewFromBigInt(tx.GasPrice(), 0)
+	batch.SubmittedAt = batch.UpdatedAt
+	batch.Status = models.IncentiveBatchSubmitted
+	batch.LastError = ""
+
+	if err := db.Save(batch).Error; err != nil {
+		return err
+	}
+
+	if err := incentiveContract.Contract.Send(tx); err != nil {
+		batch.LastError = err.Error()
+		db.Save(batch)
+
+		return err
+	}
+
+	log.Println("transaction hash: " + tx.Hash().String())
+
+	return nil
+}
+
+// replaceBatch sends the grantIncentives transaction of a batch not mined
+// in time again, with the same nonce and a gas price raised by
+// incentives.gas_price_bump_percent so that only one of them is mined.
+func (incentiveContract *IncentiveContract) replaceBatch(batch *models.IncentiveBatch, db *gorm.DB) {
+
+	bump := config.GetConfig().GetInt64("incentives.gas_price_bump_percent")
+
+	if bump < 10 {
+		bump = 10
+	}
+
+	gasPrice := batch.GasPrice.Coefficient()
+	gasPrice.Mul(gasPrice, big.NewInt(100 + bump))
+	gasPrice.Div(gasPrice, big.NewInt(100))
+
+	nonce := batch.Nonce
+
+	batch.UpdatedAt = uint(time.Now().Unix())
+
+	tx, err := incentiveContract.signGrant(incentiveContract.getBatchIncentives(batch, db), &nonce, gasPrice)
+
+	if err != nil {
+		batch.LastError = err.Error()
+		db.Save(batch)
+
+		return
+	}
+
+	batch.AddTxHash(tx.Hash().String())
+	batch.GasPrice = decimal.NewFromBigInt(tx.GasPrice(), 0)
+	batch.SubmittedAt = batch.UpdatedAt
+	batch.LastError = ""
+
+	if err := db.Save(batch).Error; err != nil {
+		return
+	}
+
+	if err := incentiveContract.Contract.Send(tx); err != nil {
+		// The previous transaction may just have been mined
+		batch.LastError = err.Error()
+		db.Save(batch)
+
+		return
+	}
+
+	log.Println("replaced incentive batch #" + strconv.FormatUint(uint64(batch.ID), 10) + " transaction with " + tx.Hash().String())
+}
+
+func (incentiveContract *IncentiveContract) getBatchIncentives(batch *models.IncentiveBatch, db *gorm.DB) map[string]*big.Int {
+
+	var incs []models.Incentive
+
+	db.Table("incentives").Where("batch_id = ?", batch.ID).Find(&incs)
+
+	userIncentives := make(map[string]*big.Int)
+
+	for _, incentive := range incs {
+
+		amount := new(big.Int).Set(incentive.Amount.Coefficient())
+
+		if userIncentives[incentive.UserAddress] != nil {
+			userIncentives[incentive.UserAddress].Add(userIncentives[incentive.UserAddress], amount)
+		} else {
+			userIncentives[incentive.UserAddress] = amount
+		}
+	}
+
+	return userIncentives
+}
+
+// ProcessIncentiveBatches checks the receipts of submitted batches. Records
+// of successful batches are marked paid, failed batches are submitted again
+// until incentives.max_attempts is reached, then abandoned along with
+// their records. Transactions not mined after
+// incentives.submit_timeout_seconds are replaced. The guard is checked
+// before each transaction.
+func (incentiveContract *IncentiveContract) ProcessIncentiveBatches (guard SubmitGuard, db *gorm.DB) error {
+
+	c := config.GetConfig()
+
+	client, err := incentiveContract.Contract.GetEthClient()
+
+	if err != nil {
+		return err
+	}
+
+	duration, err := time.ParseDuration(c.GetString("eth_node.timeout"))
+
+	if err != nil {
+		return err
+	}
+
+	timeout := uint(c.GetInt64("incentives.submit_timeout_seconds"))
+
+	var submitted []models.IncentiveBatch
+
+	db.Where("status = ?", models.IncentiveBatchSubmitted).Order("id asc").Find(&submitted)
+
+	if len(submitted) != 0 {
+
+		// The account nonce is read before the receipts so that a nonce
+		// used without any receipt of the batch means another transaction
+		// took it
+
+		ctx, cancel := context.WithTimeout(context.Background(), duration)
+
+		accountNonce, err := client.NonceAt(ctx, account.GetNodeAccount().Address, nil)
+
+		cancel()
+
+		if err != nil {
+			return err
+		}
+
+		for _, batch := range submitted {
 
-				if len(userIncentives) == 100 {
+			receipt, err := incentiveContract.getBatchReceipt(&batch, duration)
 
-					// Call contract to distribute
-					if err := incentiveContract.Distribute(userIncentives); err != nil {
+			if err != nil {
+				return err
+			}
+
+			now := uint(time.Now().Unix())
+
+			if receipt == nil {
+
+				if accountNonce > batch.Nonce {
+					batch.Status = models.IncentiveBatchFailed
+					batch.LastError = "nonce used by another transaction"
+					batch.UpdatedAt = now
+					db.Save(&batch)
+				} else if timeout > 0 && now > batch.SubmittedAt + timeout {
+
+					if err := guard.Check(); err != nil {
 						return err
 					}
 
-					// Clear distributed
-					userIncentives = make(map[string]*big.Int)
+					incentiveContract.replaceBatch(&batch, db)
 				}
+
+				continue
 			}
-		}
 
-		currentBatchOffset = currentBatchOffset + batchSize
+			tx := db.Begin()
+
+			batch.UpdatedAt = now
+			batch.TxHash = receipt.TxHash.Hex()
+
+			if receipt.Status == types.ReceiptStatusSuccessful {
+
+				batch.Status = models.IncentiveBatchConfirmed
+
+				in := tx.Table("incentives").Where("batch_id = ?", batch.ID)
+				in.Updates(map[string]interface{}{"status": models.IncentivesPaid})
+
+			} else {
+				batch.Status = models.IncentiveBatchFailed
+				batch.LastError = "transaction " + batch.TxHash + " failed"
+			}
+
+			tx.Save(&batch)
+			tx.Commit()
+		}
 	}
 
-	if len(userIncentives) != 0 {
-		if err := incentiveContract.Distribute(userIncentives); err != nil {
+	maxAttempts := c.GetInt("incentives.max_attempts")
+
+	// Abandoned records are no longer paid by the node, they are listed
+	// for the operator
+
+	var exhausted []models.IncentiveBatch
+
+	db.Where("status = ?", models.IncentiveBatchFailed).Where("attempts >= ?", maxAttempts).Find(&exhausted)
+
+	for _, batch := range exhausted {
+
+		log.Println("abandoning incentive batch #" + strconv.FormatUint(uint64(batch.ID), 10) + " after " + strconv.FormatUint(uint64(batch.Attempts), 10) + " attempts")
+
+		tx := db.Begin()
+
+		batch.Status = models.IncentiveBatchAbandoned
+		batch.UpdatedAt = uint(time.Now().Unix())
+		tx.Save(&batch)
+
+		in := tx.Table("incentives").Where("batch_id = ? AND status = ?", batch.ID, models.IncentivesPaying)
+		in.Updates(map[string]interface{}{"status": models.IncentivesAbandoned})
+
+		if err := tx.Commit().Error; err != nil {
 			return err
 		}
 	}
 
-	// Finish calculation
+	var failed []models.IncentiveBatch
 
-	in2 := db.Table("incentives").Where("status = ?", models.IncentivesCalculating)
-	in2.Updates(map[string]interface{}{"status": models.IncentivesPaid})
+	db.Where("status = ?", models.IncentiveBatchFailed).Where("attempts < ?", maxAttempts).Order("id asc").Find(&failed)
+
+	for _, batch := range failed {
+
+		if err := guard.Check(); err != nil {
+			return err
+		}
+
+		log.Println("retrying incentive batch #" + strconv.FormatUint(uint64(batch.ID), 10))
+
+		if err := incentiveContract.submitBatch(&batch, db); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
-func (incentiveContract *IncentiveContract) Distribute(incentives map[string]*big.Int) error {
-
-	//var userAddresses []common.Address
-	//var userAmounts []*big.Int
-	//
-	//for address, amount := range incentives {
-	//	userAddresses = append(userAddresses, common.HexToAddress(address))
-	//	userAmounts = append(userAmounts, amount)
-	//}
-	//
-	//txHash, err := incentiveContract.Contract.Execute(
-	//	"grantIncentives",
-	//	userAddresses,
-	//	userAmounts )
-	//
-	//if err != nil {
-	//	return err
-	//}
-	//
-	//log.Println("transaction hash: " + txHash)
+// getBatchReceipt returns the receipt of the transaction of the batch that
+// was mined, nil if none was.
+func (incentiveContract *IncentiveContract) getBatchReceipt(batch *models.IncentiveBatch, duration time.Duration) (*types.Receipt, error) {
 
-	return nil
+	client, err := incentiveContract.Contract.GetEthClient()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, txHash := range batch.GetTxHashes() {
+
+		ctx, cancel := context.WithTimeout(context.Background(), duration)
+
+		receipt, err := client.TransactionReceipt(ctx, common.HexToHash(txHash))
+
+		cancel()
+
+		if err == ethereum.NotFound {
+			// Not mined
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return receipt, nil
+	}
+
+	return nil, nil
+}
+
+func (incentiveContract *IncentiveContract) Distribute(incentives map[string]*big.Int) (string, error) {
+
+	tx, err := incentiveContract.submitGrant(incentives, nil, nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	return tx.Hash().String(), nil
+}
+
+func (incentiveContract *IncentiveContract) submitGrant(incentives map[string]*big.Int, nonce *uint64, gasPrice *big.Int) (*types.Transaction, error) {
+
+	tx, err := incentiveContract.signGrant(incentives, nonce, gasPrice)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := incentiveContract.Contract.Send(tx); err != nil {
+		return nil, err
+	}
+
+	log.Println("transaction hash: " + tx.Hash().String())
+
+	return tx, nil
+}
+
+func (incentiveContract *IncentiveContract) signGrant(incentives map[string]*big.Int, nonce *uint64, gasPrice *big.Int) (*types.Transaction, error) {
+
+	var userAddresses []string
+
+	for address := range incentives {
+		userAddresses = append(userAddresses, address)
+	}
+
+	sort.Strings(userAddresses)
+
+	var recipients []common.Address
+	var amounts []*big.Int
+
+	for _, address := range userAddresses {
+		recipients = append(recipients, common.HexToAddress(address))
+		amounts = append(amounts, incentives[address])
+	}
+
+	return incentiveContract.Contract.Sign(nonce, gasPrice, "grantIncentives", recipients, amounts)
 }
@@ -0,0 +1,215 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package contracts
+
+import (
+	"context"
+	"log"
+	"math/big"
+	"strconv"
+	"time"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/jinzhu/gorm"
+	"github.com/primasio/primas-node/config"
+	"github.com/primasio/primas-node/models"
+)
+
+// LockDeposit sends the transaction locking a recorded deposit,
+// tokenTimeLock for time deposits and tokenStatusLock otherwise. The lock
+// counts against the spendable balance from the time it is recorded. It
+// is pending until the Lock event is synchronized, ProcessTokenLocks
+// drops it if the transaction fails.
+func (tokenContract *TokenContract) LockDeposit(lock *models.TokenLock, db *gorm.DB) error {
+
+	userAddress := common.HexToAddress(lock.UserAddress)
+	dna := []byte(lock.ResourceDNA)
+	amount := lock.Amount.Coefficient()
+
+	var txHash string
+	var err error
+
+	if lock.IsTimeLock() {
+		txHash, err = tokenContract.Binding.TokenTimeLock(userAddress, dna, amount, big.NewInt(int64(lock.Expire)))
+	} else {
+		txHash, err = tokenContract.Binding.TokenStatusLock(userAddress, dna, amount)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	lock.LockTxHash = txHash
+	lock.TxStatus = models.TxStatusPending
+
+	return db.Save(lock).Error
+}
+
+// SubmitUnlock sends the transaction releasing the lock: tokenTimeUnlock
+// for time locks, tokenStatusUnlock for status locks.
+func (tokenContract *TokenContract) SubmitUnlock(lock *models.TokenLock, db *gorm.DB) error {
+
+	userAddress := common.HexToAddress(lock.UserAddress)
+	dna := []byte(lock.ResourceDNA)
+
+	var txHash string
+	var err error
+
+	if lock.IsTimeLock() {
+		txHash, err = tokenContract.Binding.TokenTimeUnlock(userAddress, dna)
+	} else {
+		txHash, err = tokenContract.Binding.TokenStatusUnlock(userAddress, dna)
+	}
+
+	lock.UnlockAttempts = lock.UnlockAttempts + 1
+
+	if err != nil {
+		lock.UnlockStatus = models.TokenLockUnlockFailed
+		lock.UnlockError = err.Error()
+		db.Save(lock)
+
+		return err
+	}
+
+	lock.UnlockStatus = models.TokenLockUnlockSubmitted
+	lock.UnlockTxHash = txHash
+	lock.UnlockError = ""
+	db.Save(lock)
+
+	return nil
+}
+
+// ProcessTokenLocks sends the deposits left unsent by their request,
+// drops those whose lock transaction failed and checks the receipts of the
+// submitted unlock transactions, then submits the unlock of the time locks
+// that expired. Time locks of deleted resources stay queued until then.
+// Failed unlocks are submitted again until locks.max_attempts is reached,
+// the guard being checked before each transaction. Released locks are
+// archived when their Lock event is synchronized.
+func (tokenContract *TokenContract) ProcessTokenLocks(guard SubmitGuard, db *gorm.DB) error {
+
+	c := config.GetConfig()
+
+	client, err := tokenContract.Contract.GetEthClient()
+
+	if err != nil {
+		return err
+	}
+
+	duration, err := time.ParseDuration(c.GetString("eth_node.timeout"))
+
+	if err != nil {
+		return err
+	}
+
+	maxAttempts := uint(c.GetInt("locks.max_attempts"))
+	batchSize := c.GetInt("locks.batch_size")
+
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+
+	// Requests send their deposit right after committing, older ones
+	// were left unsent
+
+	unsentBefore := uint(time.Now().Add(-time.Minute).Unix())
+
+	for _, lock := range models.GetUnsentTokenLocks(unsentBefore, batchSize, db) {
+
+		if err := guard.Check(); err != nil {
+			return err
+		}
+
+		log.Println("locking deposit #" + strconv.FormatUint(uint64(lock.ID), 10))
+
+		if err := tokenContract.LockDeposit(&lock, db); err != nil {
+			return err
+		}
+	}
+
+	for _, lock := range models.GetPendingTokenLocks(db) {
+
+		ctx, cancel := context.WithTimeout(context.Background(), duration)
+
+		receipt, err := client.TransactionReceipt(ctx, common.HexToHash(lock.LockTxHash))
+
+		cancel()
+
+		if err == ethereum.NotFound {
+			// Not mined yet
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			log.Println("dropping deposit #" + strconv.FormatUint(uint64(lock.ID), 10) + ", transaction " + lock.LockTxHash + " failed")
+			db.Delete(&lock)
+		}
+	}
+
+	var submitted []models.TokenLock
+
+	db.Where("unlock_status = ?", models.TokenLockUnlockSubmitted).Order("id asc").Find(&submitted)
+
+	for _, lock := range submitted {
+
+		ctx, cancel := context.WithTimeout(context.Background(), duration)
+
+		receipt, err := client.TransactionReceipt(ctx, common.HexToHash(lock.UnlockTxHash))
+
+		cancel()
+
+		if err == ethereum.NotFound {
+			// Not mined yet
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if receipt.Status == types.ReceiptStatusSuccessful {
+			lock.UnlockStatus = models.TokenLockUnlockMined
+			db.Save(&lock)
+		} else {
+			lock.UnlockStatus = models.TokenLockUnlockFailed
+			lock.UnlockError = "transaction " + lock.UnlockTxHash + " failed"
+			db.Save(&lock)
+		}
+	}
+
+	expired := models.GetExpiredTokenLocks(maxAttempts, batchSize, db)
+
+	for _, lock := range expired {
+
+		if err := guard.Check(); err != nil {
+			return err
+		}
+
+		log.Println("unlocking token lock #" + strconv.FormatUint(uint64(lock.ID), 10))
+
+		if err := tokenContract.SubmitUnlock(&lock, db); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -31,6 +31,7 @@ var metadataContract *MetadataContract = nil
 
 type MetadataContract struct {
 	Contract *Contract
+	Binding *MetadataBinding
 }
 
 func GetMetadataContract() (*MetadataContract, error) {
@@ -46,48 +47,19 @@ func GetMetadataContract() (*MetadataContract, error) {
 			return nil, err
 		}
 
+		contract.Binding = NewMetadataBinding(contract.Contract)
+
 		metadataContract = contract
 	}
 
 	return metadataContract, nil
 }
 
-type PublishLogArgs struct {
-	Title               []byte
-	ContentHash         []byte
-	License             []byte
-	Extras              []byte
-	BlockHash           []byte
-	Signature           []byte
-	DNA                 []byte
-}
-
-type LikeLogArgs struct {
-	ArticleDNA          []byte
-	GroupDNA            []byte
-	Signature           []byte
-}
-
-type CommentLogArgs struct {
-	ArticleDNA          []byte
-	GroupDNA            []byte
-	ContentHash         []byte
-	Signature           []byte
-}
-
-type ShareLogArgs struct {
-	ArticleDNA          []byte
-	GroupsDNA           []byte
-	Signature           []byte
-}
-
 func (metadataContract *MetadataContract) HandleEvent(eventLog *types.Log, db *gorm.DB) error {
 
 	// We only need event name topic
 
-	topic := eventLog.Topics[0]
-
-	name, err := metadataContract.Contract.GetEventNameByTopicHash(topic.Hex())
+	name, err := metadataContract.Contract.GetEventName(eventLog)
 
 	if err != nil {
 		return err
@@ -113,9 +85,7 @@ func (metadataContract *MetadataContract) HandleEvent(eventLog *types.Log, db *g
 
 func (metadataContract *MetadataContract) handlePublish(name string, eventLog *types.Log, db *gorm.DB) error {
 
-	args := &PublishLogArgs{}
-
-	err := metadataContract.Contract.ABI.Unpack(args, name, eventLog.Data)
+	args, err := metadataContract.Binding.UnpackPublishLog(eventLog)
 
 	if err != nil {
 		return err
@@ -136,7 +106,7 @@ func (metadataContract *MetadataContract) handlePublish(name string, eventLog *t
 	if article.ID == 0 {
 
 		// Article does not exists
-		author, err := crypto.ExtractUserFromSignature(article.GetSignatureBaseString(), article.Signature)
+		author, err := verifyEventSignature(eventLog, article.GetSignatureBaseString(), crypto.ArticleMessage(article), article.Signature, db)
 
 		if err != nil {
 			return err
@@ -148,17 +118,23 @@ func (metadataContract *MetadataContract) handlePublish(name string, eventLog *t
 		article.CreatedAt = uint(time.Now().Unix())
 	}
 
+	blockTime, err := getBlockTime(eventLog.BlockNumber)
+
+	if err != nil {
+		return err
+	}
+
 	article.TxStatus = models.TxStatusConfirmed
+	article.PublishBlockNumber = eventLog.BlockNumber
+	article.PublishBlockTime = blockTime
 
 	db.Save(article)
 
-	return nil
+	return recordRelay(eventLog, models.NodeActivityArticle, getSyncLag(article.BlockHash, eventLog), db)
 }
 
 func (metadataContract *MetadataContract) handleLike(name string, eventLog *types.Log, db *gorm.DB) error {
-	args := &LikeLogArgs{}
-
-	err := metadataContract.Contract.ABI.Unpack(args, name, eventLog.Data)
+	args, err := metadataContract.Binding.UnpackLikeLog(eventLog)
 
 	if err != nil {
 		return err
@@ -172,7 +148,7 @@ func (metadataContract *MetadataContract) handleLike(name string, eventLog *type
 	sigBase := like.GetSignatureBaseString()
 	signature := hex.EncodeToString(args.Signature)
 
-	if user, err := crypto.ExtractUserFromSignature(sigBase, signature); err == nil {
+	if user, err := verifyEventSignature(eventLog, sigBase, crypto.LikeMessage(like), signature, db); err == nil {
 
 		like.GroupMemberAddress = user.Address
 
@@ -198,15 +174,19 @@ func (metadataContract *MetadataContract) handleLike(name string, eventLog *type
 
 	db.Save(article)
 
-	models.LikeArticleIncentive(like, db)
+	blockTime, err := getBlockTime(eventLog.BlockNumber)
 
-	return nil
+	if err != nil {
+		return err
+	}
+
+	models.LikeArticleIncentive(like, eventLog.BlockNumber, blockTime, db)
+
+	return recordRelay(eventLog, models.NodeActivityInteraction, 0, db)
 }
 
 func (metadataContract *MetadataContract) handleComment(name string, eventLog *types.Log, db *gorm.DB) error {
-	args := &CommentLogArgs{}
-
-	err := metadataContract.Contract.ABI.Unpack(args, name, eventLog.Data)
+	args, err := metadataContract.Binding.UnpackCommentLog(eventLog)
 
 	if err != nil {
 		return err
@@ -221,7 +201,7 @@ func (metadataContract *MetadataContract) handleComment(name string, eventLog *t
 	sigBase := comment.GetSignatureBaseString()
 	signature := hex.EncodeToString(args.Signature)
 
-	if user, err := crypto.ExtractUserFromSignature(sigBase, signature); err == nil {
+	if user, err := verifyEventSignature(eventLog, sigBase, crypto.CommentMessage(comment), signature, db); err == nil {
 
 		comment.GroupMemberAddress = user.Address
 
@@ -247,15 +227,19 @@ func (metadataContract *MetadataContract) handleComment(name string, eventLog *t
 
 	db.Save(article)
 
-	models.CommentArticleIncentive(comment, db)
+	blockTime, err := getBlockTime(eventLog.BlockNumber)
 
-	return nil
+	if err != nil {
+		return err
+	}
+
+	models.CommentArticleIncentive(comment, eventLog.BlockNumber, blockTime, db)
+
+	return recordRelay(eventLog, models.NodeActivityInteraction, 0, db)
 }
 
 func (metadataContract *MetadataContract) handleShare(name string, eventLog *types.Log, db *gorm.DB) error {
-	args := &ShareLogArgs{}
-
-	err := metadataContract.Contract.ABI.Unpack(args, name, eventLog.Data)
+	args, err := metadataContract.Binding.UnpackShareLog(eventLog)
 
 	if err != nil {
 		return err
@@ -269,7 +253,7 @@ func (metadataContract *MetadataContract) handleShare(name string, eventLog *typ
 	sigBase := shareBatch.GetSignatureBaseString()
 	signature := hex.EncodeToString(args.Signature)
 
-	if user, err := crypto.ExtractUserFromSignature(sigBase, signature); err == nil {
+	if user, err := verifyEventSignature(eventLog, sigBase, crypto.ShareMessage(shareBatch), signature, db); err == nil {
 
 		shareBatch.GroupMemberAddress = user.Address
 
@@ -277,6 +261,12 @@ func (metadataContract *MetadataContract) handleShare(name string, eventLog *typ
 		return err
 	}
 
+	blockTime, err := getBlockTime(eventLog.BlockNumber)
+
+	if err != nil {
+		return err
+	}
+
 	for _, groupDNA := range shareBatch.GroupDNAs {
 
 		groupArticle := &models.GroupArticle{}
@@ -302,7 +292,7 @@ func (metadataContract *MetadataContract) handleShare(name string, eventLog *typ
 
 		db.Save(group)
 
-		models.ShareArticleIncentive(groupArticle, db)
+		models.ShareArticleIncentive(groupArticle, eventLog.BlockNumber, blockTime, db)
 	}
 
 	article := &models.Article{}
@@ -316,5 +306,5 @@ func (metadataContract *MetadataContract) handleShare(name string, eventLog *typ
 
 	db.Set("gorm:save_associations", false).Save(article)
 
-	return nil
+	return recordRelay(eventLog, models.NodeActivityInteraction, 0, db)
 }
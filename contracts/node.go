@@ -0,0 +1,119 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package contracts
+
+import (
+	"context"
+	"log"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/jinzhu/gorm"
+	"github.com/primasio/primas-node/config"
+	"github.com/primasio/primas-node/models"
+	"time"
+)
+
+// recordRelay credits the node that sent the transaction of the event log.
+// Only transactions sent to the content contract are relayed by nodes,
+// users calling the metadata contract directly are not nodes. The sender
+// is prefetched before the synchronization transaction, a relay that
+// cannot be looked up is not credited rather than failing the range.
+func recordRelay(eventLog *types.Log, activityType uint, syncLag uint64, db *gorm.DB) error {
+
+	relayer, ok := getPrefetchedRelayer(eventLog.TxHash)
+
+	if !ok {
+
+		duration, err := time.ParseDuration(config.GetConfig().GetString("eth_node.timeout"))
+
+		if err != nil {
+			return err
+		}
+
+		relayer, err = getRelayer(eventLog.TxHash, duration)
+
+		if err != nil {
+			log.Println("relay of " + eventLog.TxHash.Hex() + " not credited: " + err.Error())
+			return nil
+		}
+	}
+
+	if relayer.Big().Sign() == 0 {
+		return nil
+	}
+
+	models.RecordNodeActivity(relayer.Hex(), activityType, eventLog.TxHash.Hex(), eventLog.BlockNumber, syncLag, db)
+
+	return nil
+}
+
+// getRelayer returns the sender of the transaction if it was sent to the
+// content contract, the zero address otherwise.
+func getRelayer(txHash common.Hash, duration time.Duration) (common.Address, error) {
+
+	contentContract, err := GetContentContract()
+
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	client, err := contentContract.Contract.GetEthClient()
+
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	defer cancel()
+
+	tx, _, err := client.TransactionByHash(ctx, txHash)
+
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	if tx.To() == nil {
+		return common.Address{}, nil
+	}
+
+	if _, err := contentContract.Contract.GetDeploymentByAddress(*tx.To()); err != nil {
+		return common.Address{}, nil
+	}
+
+	var signer types.Signer = types.HomesteadSigner{}
+
+	if tx.Protected() {
+		signer = types.NewEIP155Signer(tx.ChainId())
+	}
+
+	return types.Sender(signer, tx)
+}
+
+// getSyncLag returns how many blocks the chain head seen by the node
+// relaying an article, whose hash the article references, was behind the
+// block the article was published in. Hashes of no block of the chain
+// count as lagging since the first block.
+func getSyncLag(blockHash string, eventLog *types.Log) uint64 {
+
+	referenced, ok := getReferencedBlockNumber(common.HexToHash(blockHash))
+
+	if !ok || referenced > eventLog.BlockNumber {
+		return eventLog.BlockNumber
+	}
+
+	return eventLog.BlockNumber - referenced
+}
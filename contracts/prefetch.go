@@ -0,0 +1,185 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package contracts
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/primasio/primas-node/config"
+)
+
+// chainData is what the event handlers read from the Ethereum node besides
+// the logs. It is fetched before the transaction of a synchronized range
+// is opened, so that a failing call retries the range without holding it.
+type chainData struct {
+	sync.Mutex
+	blockTimes    map[uint64]uint64
+	relayers      map[common.Hash]common.Address
+	blockNumbers  map[common.Hash]uint64
+}
+
+var prefetched = &chainData{}
+
+// PrefetchChainData fetches the block times of the logs, the senders of
+// the transactions relayed through the content contract and the blocks
+// referenced by published articles.
+func PrefetchChainData(logs []types.Log) error {
+
+	client, err := new(Contract).GetEthClient()
+
+	if err != nil {
+		return err
+	}
+
+	duration, err := time.ParseDuration(config.GetConfig().GetString("eth_node.timeout"))
+
+	if err != nil {
+		return err
+	}
+
+	metadataContract, err := GetMetadataContract()
+
+	if err != nil {
+		return err
+	}
+
+	data := &chainData{
+		blockTimes: make(map[uint64]uint64),
+		relayers: make(map[common.Hash]common.Address),
+		blockNumbers: make(map[common.Hash]uint64) }
+
+	for i := range logs {
+
+		eventLog := &logs[i]
+
+		if _, ok := data.blockTimes[eventLog.BlockNumber]; !ok {
+
+			ctx, cancel := context.WithTimeout(context.Background(), duration)
+
+			header, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(eventLog.BlockNumber))
+
+			cancel()
+
+			if err != nil {
+				return err
+			}
+
+			data.blockTimes[eventLog.BlockNumber] = header.Time.Uint64()
+		}
+
+		if _, err := metadataContract.Contract.GetDeploymentByAddress(eventLog.Address); err != nil {
+			continue
+		}
+
+		if _, ok := data.relayers[eventLog.TxHash]; !ok {
+
+			relayer, err := getRelayer(eventLog.TxHash, duration)
+
+			if err != nil {
+				return err
+			}
+
+			data.relayers[eventLog.TxHash] = relayer
+		}
+
+		name, err := metadataContract.Contract.GetEventName(eventLog)
+
+		if err != nil || name != "PublishLog" {
+			continue
+		}
+
+		args, err := metadataContract.Binding.UnpackPublishLog(eventLog)
+
+		if err != nil {
+			continue
+		}
+
+		hash := common.HexToHash(string(args.BlockHash))
+
+		ctx, cancel := context.WithTimeout(context.Background(), duration)
+
+		header, err := client.HeaderByHash(ctx, hash)
+
+		cancel()
+
+		if err == ethereum.NotFound {
+			// Not a block of this chain
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		data.blockNumbers[hash] = header.Number.Uint64()
+	}
+
+	prefetched.Lock()
+	prefetched.blockTimes = data.blockTimes
+	prefetched.relayers = data.relayers
+	prefetched.blockNumbers = data.blockNumbers
+	prefetched.Unlock()
+
+	return nil
+}
+
+// ClearChainData drops the data prefetched for a range.
+func ClearChainData() {
+
+	prefetched.Lock()
+	prefetched.blockTimes = nil
+	prefetched.relayers = nil
+	prefetched.blockNumbers = nil
+	prefetched.Unlock()
+}
+
+func getPrefetchedBlockTime(blockNumber uint64) (uint64, bool) {
+
+	prefetched.Lock()
+	defer prefetched.Unlock()
+
+	blockTime, ok := prefetched.blockTimes[blockNumber]
+
+	return blockTime, ok
+}
+
+func getPrefetchedRelayer(txHash common.Hash) (common.Address, bool) {
+
+	prefetched.Lock()
+	defer prefetched.Unlock()
+
+	relayer, ok := prefetched.relayers[txHash]
+
+	return relayer, ok
+}
+
+// getReferencedBlockNumber returns the number of a block referenced by
+// hash, false if it is not a block of the chain.
+func getReferencedBlockNumber(hash common.Hash) (uint64, bool) {
+
+	prefetched.Lock()
+	defer prefetched.Unlock()
+
+	blockNumber, ok := prefetched.blockNumbers[hash]
+
+	return blockNumber, ok
+}
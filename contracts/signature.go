@@ -0,0 +1,237 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package contracts
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strconv"
+	"sync"
+	"time"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/jinzhu/gorm"
+	"github.com/primasio/primas-node/config"
+	"github.com/primasio/primas-node/crypto"
+	"github.com/primasio/primas-node/models"
+)
+
+// GetSignatureDomain returns the EIP-712 domain of the actions recorded by
+// the contract, bound to its active deployment.
+func GetSignatureDomain(contractName string) (*crypto.Domain, error) {
+
+	contract, err := GetContractByName(contractName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newSignatureDomain(contract.Address), nil
+}
+
+func newSignatureDomain(address common.Address) *crypto.Domain {
+	return crypto.NewDomain(big.NewInt(config.GetConfig().GetInt64("eth_node.chain_id")), address)
+}
+
+// LegacySignaturesAccepted reports whether signatures of the Keccak256 of
+// the bare message are accepted, which signatures.legacy allows.
+func LegacySignaturesAccepted() bool {
+
+	c := config.GetConfig()
+
+	if !c.IsSet("signatures.legacy") {
+		return true
+	}
+
+	return c.GetBool("signatures.legacy")
+}
+
+// VerifySubmission returns the signer of an action submitted to the node
+// on behalf of claimedAddress, and the signature to record on Blockchain.
+// Typed data signatures must have an unused nonce and a deadline not yet
+// passed. The nonce is used in db, which should be the transaction of the
+// request committed only once the action is submitted. When the signature does not recover to
+// claimedAddress, it may be a smart contract wallet such as a multisig,
+// which is then asked to verify it.
+func VerifySubmission(contractName string, claimedAddress string, legacyMessage string, message *crypto.TypedMessage, signature string, db *gorm.DB) (*models.User, string, error) {
+
+	domain, err := GetSignatureDomain(contractName)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	claimed := common.HexToAddress(claimedAddress)
+	allowLegacy := LegacySignaturesAccepted()
+
+	signer, sig, err := crypto.RecoverClaimedSigner(claimed, legacyMessage, message, domain, signature, allowLegacy)
+
+	if err != nil || signer != claimed {
+
+		walletSig, walletErr := crypto.NewWalletSignature(claimed, signature)
+
+		if walletErr == nil {
+			walletErr = verifyWalletSignature(walletSig, legacyMessage, message, domain, allowLegacy, nil, db)
+		}
+
+		if rejected, ok := walletErr.(*RejectedEventError); ok {
+			walletErr = errors.New(rejected.Reason)
+		}
+
+		if walletErr == nil {
+			signer, sig, err = claimed, walletSig, nil
+		} else if err != nil {
+			err = walletErr
+		}
+	}
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	if sig.Scheme == crypto.SignatureTypedData && signer.Hex() == claimedAddress {
+
+		maxDeadline := config.GetConfig().GetInt64("signatures.max_deadline_seconds")
+
+		if err := models.UseSignatureNonce(signer.Hex(), sig.Nonce, sig.Deadline, maxDeadline, db); err != nil {
+			return nil, "", err
+		}
+	}
+
+	return &models.User{ Address: signer.Hex() }, sig.Encode(), nil
+}
+
+// RejectedEventError is returned for an event the node does not apply, such
+// as an action replaying a signature. The event is skipped rather than
+// stopping the synchronization.
+type RejectedEventError struct {
+	Reason string
+}
+
+func (e *RejectedEventError) Error() string {
+	return "event rejected: " + e.Reason
+}
+
+// verifyEventSignature returns the signer of an action recorded on chain.
+// Legacy signatures stay accepted up to signatures.legacy_until_block so
+// that earlier blocks can still be synchronized. Typed data signatures
+// must be used before their deadline at the block time, and their nonce
+// by one event only. Smart contract wallet signatures name their wallet,
+// which is asked to verify them at the block of the event.
+func verifyEventSignature(eventLog *types.Log, legacyMessage string, message *crypto.TypedMessage, signature string, db *gorm.DB) (*models.User, error) {
+
+	allowLegacy := LegacySignaturesAccepted() ||
+		eventLog.BlockNumber <= uint64(config.GetConfig().GetInt64("signatures.legacy_until_block"))
+
+	domain := newSignatureDomain(eventLog.Address)
+
+	sig, err := crypto.ParseSignature(signature)
+
+	if err != nil {
+		return nil, &RejectedEventError{ Reason: err.Error() }
+	}
+
+	var signer common.Address
+
+	if sig.IsWalletSignature() {
+		signer = sig.Wallet
+		err = verifyWalletSignature(sig, legacyMessage, message, domain, allowLegacy, new(big.Int).SetUint64(eventLog.BlockNumber), db)
+	} else {
+		signer, err = sig.Recover(legacyMessage, message, domain, allowLegacy)
+
+		if err != nil {
+			err = &RejectedEventError{ Reason: err.Error() }
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if sig.Scheme == crypto.SignatureTypedData {
+
+		blockTime, err := getBlockTime(eventLog.BlockNumber)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if sig.Deadline < blockTime {
+			return nil, &RejectedEventError{ Reason: "signature expired before block " + strconv.FormatUint(eventLog.BlockNumber, 10) }
+		}
+
+		if !models.UseSignatureNonceOnChain(signer.Hex(), sig.Nonce, eventLog.TxHash.Hex(), eventLog.Index, db) {
+			return nil, &RejectedEventError{ Reason: "nonce " + strconv.FormatUint(sig.Nonce, 10) + " of " + signer.Hex() + " already used" }
+		}
+
+		models.RecordSignatureNonce(signer.Hex(), sig.Nonce, db)
+	}
+
+	return &models.User{ Address: signer.Hex() }, nil
+}
+
+var blockTimeCache = struct {
+	sync.Mutex
+	times map[uint64]uint64
+}{ times: make(map[uint64]uint64) }
+
+// getBlockTime returns the timestamp of the block, prefetched for the
+// range being synchronized. Otherwise the last blocks looked up are
+// cached.
+func getBlockTime(blockNumber uint64) (uint64, error) {
+
+	if blockTime, ok := getPrefetchedBlockTime(blockNumber); ok {
+		return blockTime, nil
+	}
+
+	blockTimeCache.Lock()
+	defer blockTimeCache.Unlock()
+
+	if blockTime, ok := blockTimeCache.times[blockNumber]; ok {
+		return blockTime, nil
+	}
+
+	client, err := new(Contract).GetEthClient()
+
+	if err != nil {
+		return 0, err
+	}
+
+	duration, err := time.ParseDuration(config.GetConfig().GetString("eth_node.timeout"))
+
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	defer cancel()
+
+	header, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(blockNumber))
+
+	if err != nil {
+		return 0, err
+	}
+
+	if len(blockTimeCache.times) >= 1000 {
+		blockTimeCache.times = make(map[uint64]uint64)
+	}
+
+	blockTimeCache.times[blockNumber] = header.Time.Uint64()
+
+	return header.Time.Uint64(), nil
+}
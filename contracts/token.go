@@ -33,6 +33,7 @@ var tokenContract *TokenContract = nil
 
 type TokenContract struct {
 	Contract *Contract
+	Binding *TokenBinding
 }
 
 func GetTokenContract () (*TokenContract, error) {
@@ -47,15 +48,21 @@ func GetTokenContract () (*TokenContract, error) {
 			return nil, err
 		}
 
+		contract.Binding = NewTokenBinding(contract.Contract)
+
 		tokenContract = contract
 	}
 
 	return tokenContract, nil
 }
 
-func (tokenContract *TokenContract) Inflate() error {
+func (tokenContract *TokenContract) Inflate(guard SubmitGuard) error {
+
+	if err := guard.Check(); err != nil {
+		return err
+	}
 
-	txHash, err := tokenContract.Contract.Execute("inflate")
+	txHash, err := tokenContract.Binding.Inflate()
 
 	if err != nil {
 		return err
@@ -66,13 +73,39 @@ func (tokenContract *TokenContract) Inflate() error {
 	return nil
 }
 
+// CheckBalance compares the recorded balance of the user with the on-chain
+// balanceOf at the block the node is synchronized to.
+func (tokenContract *TokenContract) CheckBalance(user *models.User, db *gorm.DB) (*models.BalanceCheck, error) {
+
+	var blockNumber *big.Int
+
+	currentBlockNumber := models.GetState("CurrentBlockNumber", db)
+
+	if currentBlockNumber != "" {
+
+		n, ok := new(big.Int).SetString(currentBlockNumber, 10)
+
+		if !ok {
+			return nil, errors.New("invalid current block number")
+		}
+
+		blockNumber = n
+	}
+
+	balance, err := tokenContract.Binding.BalanceOf(blockNumber, common.HexToAddress(user.Address))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return models.NewBalanceCheck(user, currentBlockNumber, balance), nil
+}
+
 func (tokenContract *TokenContract) HandleEvent(eventLog *types.Log, db *gorm.DB) error {
 
 	// We only need event name topic
 
-	topic := eventLog.Topics[0]
-
-	name, err := tokenContract.Contract.GetEventNameByTopicHash(topic.Hex())
+	name, err := tokenContract.Contract.GetEventName(eventLog)
 
 	if err != nil {
 		return err
@@ -87,6 +120,10 @@ func (tokenContract *TokenContract) HandleEvent(eventLog *types.Log, db *gorm.DB
 			return tokenContract.handleInflate(name, eventLog, db)
 		case "Lock":
 			return tokenContract.handleLock(name, eventLog, db)
+		case "Approval":
+			return tokenContract.handleApproval(name, eventLog, db)
+		case "OwnershipTransferred":
+			return tokenContract.handleOwnershipTransferred(name, eventLog, db)
 		default:
 			return errors.New("unrecognized event")
 	}
@@ -96,14 +133,19 @@ func (tokenContract *TokenContract) HandleEvent(eventLog *types.Log, db *gorm.DB
 
 func (tokenContract *TokenContract) handleTransfer(name string, eventLog *types.Log, db *gorm.DB) error {
 
-	from := common.BytesToAddress(eventLog.Topics[1].Bytes())
-	to := common.BytesToAddress(eventLog.Topics[2].Bytes())
+	args, err := tokenContract.Binding.UnpackTransfer(eventLog)
 
-	value := big.NewInt(0)
-	value.SetBytes(eventLog.Data)
+	if err != nil {
+		return err
+	}
+
+	from := args.From
+	to := args.To
+	value := args.Value
 
-	// Update from
-	if from.Big().Cmp(big.NewInt(0)) != 0 {
+	// Update from, unless the UserTokenBurnLog of the same transaction
+	// already took the burned tokens off the balance
+	if from.Big().Cmp(big.NewInt(0)) != 0 && !tokenContract.burnAdjusted(to, eventLog, db) {
 		tokenContract.updateUserBalance(from.Hex(), value, db, false)
 	}
 
@@ -112,62 +154,174 @@ func (tokenContract *TokenContract) handleTransfer(name string, eventLog *types.
 		tokenContract.updateUserBalance(to.Hex(), value, db, true)
 	}
 
+	transfer := &models.TokenTransfer{}
+	transfer.CreatedAt = uint(time.Now().Unix())
+	transfer.BlockNumber = eventLog.BlockNumber
+	transfer.TxHash = eventLog.TxHash.Hex()
+	transfer.LogIndex = eventLog.Index
+	transfer.FromAddress = from.Hex()
+	transfer.ToAddress = to.Hex()
+	transfer.Value = decimal.NewFromBigInt(value, 0)
+	transfer.Classification = tokenContract.classifyTransfer(from, to)
+
+	models.RecordTokenTransfer(transfer, db)
+
 	return nil
 }
 
-func (tokenContract *TokenContract) handleInflate(name string, eventLog *types.Log, db *gorm.DB) error {
+func (tokenContract *TokenContract) burnAdjusted(to common.Address, eventLog *types.Log, db *gorm.DB) bool {
+
+	if to.Big().Sign() != 0 {
+		return false
+	}
+
+	request := &models.BurnRequest{}
+
+	db.Where(&models.BurnRequest{ TxHash: eventLog.TxHash.Hex() }).First(request)
+
+	return request.BalanceAdjusted
+}
+
+// classifyTransfer tells mints and burns, which come from or go to the
+// zero address, from incentives, which are paid by the token or the
+// incentives contract, and from transfers between users.
+func (tokenContract *TokenContract) classifyTransfer(from common.Address, to common.Address) uint {
+
+	if from.Big().Sign() == 0 {
+		return models.TokenTransferMint
+	}
+
+	if to.Big().Sign() == 0 {
+		return models.TokenTransferBurn
+	}
+
+	if _, err := tokenContract.Contract.GetDeploymentByAddress(from); err == nil {
+		return models.TokenTransferIncentive
+	}
+
+	if incentiveContract, err := GetIncentiveContract(); err == nil {
+		if _, err := incentiveContract.Contract.GetDeploymentByAddress(from); err == nil {
+			return models.TokenTransferIncentive
+		}
+	}
+
+	return models.TokenTransferUser
+}
 
-	amount := new(big.Int)
+func (tokenContract *TokenContract) handleInflate(name string, eventLog *types.Log, db *gorm.DB) error {
 
-	err := tokenContract.Contract.ABI.Unpack(&amount, name, eventLog.Data)
+	args, err := tokenContract.Binding.UnpackInflate(eventLog)
 
 	if err != nil {
 		return err
 	}
 
-	incentives.DistributeIncentives(amount, db)
+	// The incentives are granted by the assign_incentives job once the
+	// synchronization is committed
 
-	incentiveContract, err := GetIncentiveContract()
+	blockTime, err := getBlockTime(eventLog.BlockNumber)
 
 	if err != nil {
 		return err
 	}
 
-	return incentiveContract.AssignIncentives(db)
-}
+	_, err = incentives.DistributeIncentives(args.IncentivesPoolValue, eventLog.TxHash.Hex(), eventLog.BlockNumber, blockTime, db)
 
-type TokenLockArgs struct {
-	UserAddress common.Address
-	ResourceType  *big.Int
-	ResourceDNA []byte
-	Amount      *big.Int
-	Expire      *big.Int
+	return err
 }
 
 func (tokenContract *TokenContract) handleLock(name string, eventLog *types.Log, db *gorm.DB) error {
-	args := &TokenLockArgs{}
-
-	err := tokenContract.Contract.ABI.Unpack(args, name, eventLog.Data)
+	args, err := tokenContract.Binding.UnpackLock(eventLog)
 
 	if err != nil {
 		return err
 	}
 
+	userAddress := args.UserAddress.Hex()
+	resourceType := uint(args.ResourceType.Uint64())
+	resourceDNA := string(args.ResourceDNA)
+
+	// tokenTimeUnlock and tokenStatusUnlock emit Lock with a zero amount,
+	// releasing the lock of the user on the resource whichever node sent
+	// them
+
+	if args.Amount.Sign() == 0 {
+
+		released := models.GetResourceTokenLock(userAddress, resourceType, resourceDNA, db)
+
+		if released.ID == 0 {
+			return nil
+		}
+
+		return released.Archive(eventLog.TxHash.Hex(), db)
+	}
+
+	// Deposits are recorded when they are submitted, the event only
+	// confirms their amount and expiry
+
 	tokenLock := &models.TokenLock{}
 
-	tokenLock.UserAddress = args.UserAddress.Hex()
-	tokenLock.ResourceType = uint(args.ResourceType.Uint64())
-	tokenLock.ResourceDNA = string(args.ResourceDNA)
+	db.Where("lock_tx_hash = ?", eventLog.TxHash.Hex()).First(tokenLock)
+
+	if tokenLock.ID == 0 {
+		tokenLock = models.GetResourceTokenLock(userAddress, resourceType, resourceDNA, db)
+	}
+
+	if tokenLock.ID == 0 {
+		tokenLock.UserAddress = userAddress
+		tokenLock.ResourceType = resourceType
+		tokenLock.ResourceDNA = resourceDNA
+		tokenLock.CreatedAt = uint(time.Now().Unix())
+	}
 
 	tokenLock.Amount = decimal.NewFromBigInt(args.Amount, 0)
 	tokenLock.Expire = uint(args.Expire.Uint64())
-	tokenLock.CreatedAt = uint(time.Now().Unix())
+	tokenLock.LockTxHash = eventLog.TxHash.Hex()
+	tokenLock.TxStatus = models.TxStatusConfirmed
 
 	db.Save(tokenLock)
 
 	return nil
 }
 
+func (tokenContract *TokenContract) handleApproval(name string, eventLog *types.Log, db *gorm.DB) error {
+
+	args, err := tokenContract.Binding.UnpackApproval(eventLog)
+
+	if err != nil {
+		return err
+	}
+
+	value := decimal.NewFromBigInt(args.Value, 0)
+
+	models.SetAllowance(args.Owner.Hex(), args.Spender.Hex(), value, eventLog.BlockNumber, eventLog.Index, eventLog.TxHash.Hex(), db)
+
+	return nil
+}
+
+func (tokenContract *TokenContract) handleOwnershipTransferred(name string, eventLog *types.Log, db *gorm.DB) error {
+
+	args, err := tokenContract.Binding.UnpackOwnershipTransferred(eventLog)
+
+	if err != nil {
+		return err
+	}
+
+	change := &models.ContractAdminChange{}
+	change.CreatedAt = uint(time.Now().Unix())
+	change.ContractName = "token"
+	change.ContractAddress = eventLog.Address.Hex()
+	change.PreviousOwner = args.PreviousOwner.Hex()
+	change.NewOwner = args.NewOwner.Hex()
+	change.BlockNumber = eventLog.BlockNumber
+	change.LogIndex = eventLog.Index
+	change.TxHash = eventLog.TxHash.Hex()
+
+	models.RecordContractAdminChange(change, db)
+
+	return nil
+}
+
 func (tokenContract *TokenContract) updateUserBalance(address string, amount *big.Int, db *gorm.DB, isAdd bool) {
 	user := &models.User{ Address: address }
 	models.IdentifyUser(user, db)
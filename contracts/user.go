@@ -22,15 +22,20 @@ import (
 	"log"
 	"errors"
 	"github.com/ethereum/go-ethereum/common"
-	"math/big"
 	"github.com/primasio/primas-node/models"
-	"encoding/hex"
+	"github.com/primasio/primas-node/crypto"
+	"context"
+	"time"
+	"github.com/ethereum/go-ethereum"
+	"github.com/primasio/primas-node/config"
+	"github.com/shopspring/decimal"
 )
 
 var userContract *UserContract = nil
 
 type UserContract struct {
 	Contract *Contract
+	Binding *UserBinding
 }
 
 func GetUserContract () (*UserContract, error) {
@@ -45,53 +50,243 @@ func GetUserContract () (*UserContract, error) {
 			return nil, err
 		}
 
+		contract.Binding = NewUserBinding(contract.Contract)
+
 		userContract = contract
 	}
 
 	return userContract, nil
 }
 
-func (userContract *UserContract) Burn (timestamp, userAddress, signature string) error {
+// CheckBurn dry-runs the burn of the user, the contract verifying the
+// signature of the timestamp itself. Checking it locally with another
+// message could accept burns the contract rejects or the reverse.
+func (userContract *UserContract) CheckBurn (timestamp, userAddress, signature string) error {
 
-	sigBytes, err := hex.DecodeString(signature)
+	sigBytes, err := crypto.ContractSignature(signature)
 
 	if err != nil {
 		return err
 	}
 
-	address := common.HexToAddress(userAddress)
+	if err := userContract.Contract.Estimate("burn", timestamp, sigBytes, common.HexToAddress(userAddress)); err != nil {
+		return errors.New("burn rejected by the user contract: " + err.Error())
+	}
+
+	return nil
+}
+
+func (userContract *UserContract) Burn (timestamp, userAddress, signature string) (string, error) {
+
+	sigBytes, err := crypto.ContractSignature(signature)
 
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	txHash, err := userContract.Contract.Execute(
-		"burn",
+	address := common.HexToAddress(userAddress)
+
+	txHash, err := userContract.Binding.Burn(
 		timestamp,
 		sigBytes,
 		address )
 
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	log.Println("transaction hash: " + txHash)
 
+	return txHash, nil
+}
+
+// ProcessBurnRequests marks the pending burn requests whose transaction
+// failed. Successful ones are confirmed by the UserTokenBurnLog event.
+func (userContract *UserContract) ProcessBurnRequests (db *gorm.DB) error {
+
+	client, err := userContract.Contract.GetEthClient()
+
+	if err != nil {
+		return err
+	}
+
+	duration, err := time.ParseDuration(config.GetConfig().GetString("eth_node.timeout"))
+
+	if err != nil {
+		return err
+	}
+
+	var pending []models.BurnRequest
+
+	db.Where("status = ?", models.BurnRequestPending).Order("id asc").Find(&pending)
+
+	for _, request := range pending {
+
+		if request.TxHash == "" {
+
+			// The node stopped between recording the request and
+			// submitting it, the timestamp is expired by now
+
+			if request.CreatedAt < uint(time.Now().Unix() - models.GetBurnMaxAge()) {
+				request.Status = models.BurnRequestFailed
+				request.LastError = "transaction not submitted"
+				request.UpdatedAt = uint(time.Now().Unix())
+				db.Save(&request)
+			}
+
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), duration)
+
+		receipt, err := client.TransactionReceipt(ctx, common.HexToHash(request.TxHash))
+
+		cancel()
+
+		if err == ethereum.NotFound {
+			// Not mined yet
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			request.Status = models.BurnRequestFailed
+			request.LastError = "transaction " + request.TxHash + " failed"
+			request.UpdatedAt = uint(time.Now().Unix())
+			db.Save(&request)
+		}
+	}
+
 	return nil
 }
 
-type UserTokenBurnArgs struct {
-	UserAddress common.Address
-	Amount *big.Int
+var profileMethods = map[string]string{
+	"updateUserName": models.ProfileFieldName,
+	"updateUserIcon": models.ProfileFieldIcon,
+}
+
+func profileMethod(field string) (string, error) {
+
+	for method, f := range profileMethods {
+		if f == field {
+			return method, nil
+		}
+	}
+
+	return "", errors.New("unknown profile field " + field)
+}
+
+// CheckProfileUpdate dry-runs a name or icon update, the contract
+// verifying the signature of the field itself.
+func (userContract *UserContract) CheckProfileUpdate (update *models.ProfileUpdate) error {
+
+	method, err := profileMethod(update.Field)
+
+	if err != nil {
+		return err
+	}
+
+	sigBytes, err := crypto.ContractSignature(update.Signature)
+
+	if err != nil {
+		return err
+	}
+
+	if err := userContract.Contract.Estimate(method, update.Value, sigBytes, common.HexToAddress(update.UserAddress)); err != nil {
+		return errors.New(update.Field + " update rejected by the user contract: " + err.Error())
+	}
+
+	return nil
+}
+
+// UpdateProfile submits a name or icon update signed by the user. It is
+// applied by every node from the UserNameUpdateLog or UserIconUpdateLog
+// event of the user contract.
+func (userContract *UserContract) UpdateProfile (update *models.ProfileUpdate, db *gorm.DB) error {
+
+	sigBytes, err := crypto.ContractSignature(update.Signature)
+
+	if err != nil {
+		return err
+	}
+
+	address := common.HexToAddress(update.UserAddress)
+
+	var txHash string
+
+	switch update.Field {
+	case models.ProfileFieldName:
+		txHash, err = userContract.Binding.UpdateUserName(update.Value, sigBytes, address)
+	case models.ProfileFieldIcon:
+		txHash, err = userContract.Binding.UpdateUserIcon(update.Value, sigBytes, address)
+	default:
+		return errors.New("unknown profile field " + update.Field)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	log.Println("transaction hash: " + txHash)
+
+	update.TxHash = txHash
+
+	return db.Save(update).Error
+}
+
+// ProcessProfileUpdates marks the profile updates whose transaction
+// failed. Successful ones are applied from their event.
+func (userContract *UserContract) ProcessProfileUpdates (db *gorm.DB) error {
+
+	client, err := userContract.Contract.GetEthClient()
+
+	if err != nil {
+		return err
+	}
+
+	duration, err := time.ParseDuration(config.GetConfig().GetString("eth_node.timeout"))
+
+	if err != nil {
+		return err
+	}
+
+	var pending []models.ProfileUpdate
+
+	db.Where("tx_status = ?", models.TxStatusPending).Order("id asc").Find(&pending)
+
+	for _, update := range pending {
+
+		ctx, cancel := context.WithTimeout(context.Background(), duration)
+
+		receipt, err := client.TransactionReceipt(ctx, common.HexToHash(update.TxHash))
+
+		cancel()
+
+		if err == ethereum.NotFound {
+			// Not mined yet
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			update.Fail("transaction " + update.TxHash + " failed", db)
+		}
+	}
+
+	return nil
 }
 
 func (userContract *UserContract) HandleEvent(eventLog *types.Log, db *gorm.DB) error {
 
 	// We only need event name topic
 
-	topic := eventLog.Topics[0]
-
-	name, err := userContract.Contract.GetEventNameByTopicHash(topic.Hex())
+	name, err := userContract.Contract.GetEventName(eventLog)
 
 	if err != nil {
 		return err
@@ -102,6 +297,10 @@ func (userContract *UserContract) HandleEvent(eventLog *types.Log, db *gorm.DB)
 	switch name {
 		case "UserTokenBurnLog":
 			return userContract.handleUserTokenBurn(name, eventLog, db)
+		case "UserNameUpdateLog":
+			return userContract.handleUserNameUpdate(name, eventLog, db)
+		case "UserIconUpdateLog":
+			return userContract.handleUserIconUpdate(name, eventLog, db)
 		default:
 			return errors.New("unrecognized event")
 	}
@@ -109,10 +308,35 @@ func (userContract *UserContract) HandleEvent(eventLog *types.Log, db *gorm.DB)
 	return nil
 }
 
-func (userContract *UserContract) handleUserTokenBurn(name string, eventLog *types.Log, db *gorm.DB) error {
-	args := &UserTokenBurnArgs{}
+// handleUserNameUpdate applies a name update on every node, the contract
+// verified the signature of the user. The event is also emitted for
+// updates sent through internal calls, such as from a multisig wallet.
+func (userContract *UserContract) handleUserNameUpdate(name string, eventLog *types.Log, db *gorm.DB) error {
+	args, err := userContract.Binding.UnpackUserNameUpdateLog(eventLog)
 
-	err := userContract.Contract.ABI.Unpack(args, name, eventLog.Data)
+	if err != nil {
+		return err
+	}
+
+	models.ApplyProfileUpdate(args.UserAddress.Hex(), models.ProfileFieldName, args.Name, eventLog.TxHash.Hex(), db)
+
+	return nil
+}
+
+func (userContract *UserContract) handleUserIconUpdate(name string, eventLog *types.Log, db *gorm.DB) error {
+	args, err := userContract.Binding.UnpackUserIconUpdateLog(eventLog)
+
+	if err != nil {
+		return err
+	}
+
+	models.ApplyProfileUpdate(args.UserAddress.Hex(), models.ProfileFieldIcon, args.Icon, eventLog.TxHash.Hex(), db)
+
+	return nil
+}
+
+func (userContract *UserContract) handleUserTokenBurn(name string, eventLog *types.Log, db *gorm.DB) error {
+	args, err := userContract.Binding.UnpackUserTokenBurnLog(eventLog)
 
 	if err != nil {
 		return err
@@ -129,5 +353,50 @@ func (userContract *UserContract) handleUserTokenBurn(name string, eventLog *typ
 	user.TokenBurned = 1
 	db.Save(user)
 
+	// Burns submitted by other nodes have no request yet
+
+	txHash := eventLog.TxHash.Hex()
+
+	request := &models.BurnRequest{}
+
+	db.Where(&models.BurnRequest{ TxHash: txHash }).First(request)
+
+	if request.ID == 0 {
+		request.CreatedAt = uint(time.Now().Unix())
+		request.UserAddress = user.Address
+		request.TxHash = txHash
+	}
+
+	if request.Status == models.BurnRequestConfirmed {
+		return nil
+	}
+
+	request.UpdatedAt = uint(time.Now().Unix())
+	request.BlockNumber = eventLog.BlockNumber
+	request.Amount = decimal.NewFromBigInt(args.Amount, 0)
+	request.Status = models.BurnRequestConfirmed
+	request.LastError = ""
+
+	// The balance is adjusted here unless the Transfer to the zero address
+	// of the same transaction already did
+
+	burnTransfer := &models.TokenTransfer{}
+
+	db.Where("tx_hash = ? AND classification = ?", txHash, models.TokenTransferBurn).First(burnTransfer)
+
+	if burnTransfer.ID == 0 {
+
+		tokenContract, err := GetTokenContract()
+
+		if err != nil {
+			return err
+		}
+
+		tokenContract.updateUserBalance(user.Address, args.Amount, db, false)
+		request.BalanceAdjusted = true
+	}
+
+	db.Save(request)
+
 	return nil
 }
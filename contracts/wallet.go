@@ -0,0 +1,166 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package contracts
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"math/big"
+	"strings"
+	"time"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/jinzhu/gorm"
+	"github.com/primasio/primas-node/config"
+	"github.com/primasio/primas-node/crypto"
+	"github.com/primasio/primas-node/models"
+)
+
+// WalletABI is the EIP-1271 interface of smart contract wallets.
+const WalletABI = `[{"constant":true,"inputs":[{"name":"hash","type":"bytes32"},{"name":"signature","type":"bytes"}],"name":"isValidSignature","outputs":[{"name":"magicValue","type":"bytes4"}],"payable":false,"type":"function"}]`
+
+// WalletMagicValue is returned by isValidSignature for a valid signature.
+var WalletMagicValue = []byte{0x16, 0x26, 0xba, 0x7e}
+
+var walletABI abi.ABI
+var walletCaller bind.ContractCaller
+
+func init() {
+
+	parsed, err := abi.JSON(strings.NewReader(WalletABI))
+
+	if err != nil {
+		panic(err)
+	}
+
+	walletABI = parsed
+}
+
+// SetWalletCaller sets the client used to call smart contract wallets,
+// the Ethereum node by default. Tests use an in-process stub.
+func SetWalletCaller(caller bind.ContractCaller) {
+	walletCaller = caller
+}
+
+func getWalletCaller() (bind.ContractCaller, error) {
+
+	if walletCaller != nil {
+		return walletCaller, nil
+	}
+
+	return new(Contract).GetEthClient()
+}
+
+// VerifyWalletSignature reports whether the smart contract wallet accepts
+// the signature of digest, calling its isValidSignature at blockNumber, or
+// at the latest block when blockNumber is nil. An address without code
+// accepts no signature. Answers are cached, rejections at the latest
+// block for signatures.wallet_cache_seconds only.
+func VerifyWalletSignature(wallet common.Address, digest []byte, sigBytes []byte, blockNumber *big.Int, db *gorm.DB) (bool, error) {
+
+	c := config.GetConfig()
+
+	var block uint64
+
+	if blockNumber != nil {
+		block = blockNumber.Uint64()
+	}
+
+	blockBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(blockBytes, block)
+
+	cacheKey := ethcrypto.Keccak256Hash(wallet.Bytes(), digest, sigBytes, blockBytes).Hex()
+
+	if cached := models.GetWalletSignature(cacheKey, c.GetInt64("signatures.wallet_cache_seconds"), db); cached != nil {
+		return cached.Valid, nil
+	}
+
+	caller, err := getWalletCaller()
+
+	if err != nil {
+		return false, err
+	}
+
+	duration, err := time.ParseDuration(c.GetString("eth_node.timeout"))
+
+	if err != nil {
+		return false, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	defer cancel()
+
+	code, err := caller.CodeAt(ctx, wallet, blockNumber)
+
+	if err != nil {
+		return false, err
+	}
+
+	valid := false
+
+	if len(code) > 0 {
+
+		var hash [32]byte
+		copy(hash[:], digest)
+
+		input, err := walletABI.Pack("isValidSignature", hash, sigBytes)
+
+		if err != nil {
+			return false, err
+		}
+
+		output, err := caller.CallContract(ctx, ethereum.CallMsg{ To: &wallet, Data: input }, blockNumber)
+
+		if err != nil {
+			return false, err
+		}
+
+		valid = len(output) >= 4 && bytes.Equal(output[:4], WalletMagicValue)
+	}
+
+	models.RecordWalletSignature(cacheKey, wallet.Hex(), block, valid, db)
+
+	return valid, nil
+}
+
+// verifyWalletSignature checks a smart contract wallet signature against
+// the digest of its scheme at blockNumber. A signature the wallet rejects
+// gives a RejectedEventError, errors calling the wallet are returned as is.
+func verifyWalletSignature(sig *crypto.Signature, legacyMessage string, message *crypto.TypedMessage, domain *crypto.Domain, allowLegacy bool, blockNumber *big.Int, db *gorm.DB) error {
+
+	digest, err := sig.Digest(legacyMessage, message, domain, allowLegacy)
+
+	if err != nil {
+		return &RejectedEventError{ Reason: err.Error() }
+	}
+
+	valid, err := VerifyWalletSignature(sig.Wallet, digest, sig.Bytes, blockNumber, db)
+
+	if err != nil {
+		return err
+	}
+
+	if !valid {
+		return &RejectedEventError{ Reason: "signature rejected by wallet " + sig.Wallet.Hex() }
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package contracts_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/magiconair/properties/assert"
+	"github.com/primasio/primas-node/contracts"
+	"github.com/primasio/primas-node/crypto"
+	"github.com/primasio/primas-node/db"
+	"github.com/primasio/primas-node/models"
+	"github.com/primasio/primas-node/tests"
+)
+
+// stubWallet is an in-process EIP-1271 wallet accepting the signatures of
+// its owner.
+type stubWallet struct {
+	address     common.Address
+	owner       common.Address
+	calls       int
+	blockNumber *big.Int
+}
+
+func (wallet *stubWallet) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+
+	if contract == wallet.address {
+		return []byte{0x60, 0x80}, nil
+	}
+
+	return nil, nil
+}
+
+func (wallet *stubWallet) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+
+	wallet.calls++
+	wallet.blockNumber = blockNumber
+
+	// isValidSignature(bytes32 hash, bytes signature)
+	selector := ethcrypto.Keccak256([]byte("isValidSignature(bytes32,bytes)"))[:4]
+
+	if call.To == nil || *call.To != wallet.address || len(call.Data) < 100 || !bytes.Equal(call.Data[:4], selector) {
+		return nil, errors.New("execution reverted")
+	}
+
+	hash := call.Data[4:36]
+	length := new(big.Int).SetBytes(call.Data[68:100]).Int64()
+	signature := call.Data[100:100 + length]
+
+	result := make([]byte, 32)
+
+	if publicKey, err := ethcrypto.SigToPub(hash, signature); err == nil && ethcrypto.PubkeyToAddress(*publicKey) == wallet.owner {
+		copy(result, contracts.WalletMagicValue)
+	}
+
+	return result, nil
+}
+
+func TestVerifyWalletSubmission(t *testing.T) {
+
+	tests.InitTestEnv("../config/")
+
+	dbi := db.GetDb()
+
+	owner, err := ethcrypto.GenerateKey()
+	assert.Equal(t, err, nil)
+
+	other, err := ethcrypto.GenerateKey()
+	assert.Equal(t, err, nil)
+
+	walletKey, err := ethcrypto.GenerateKey()
+	assert.Equal(t, err, nil)
+
+	wallet := &stubWallet{
+		address: ethcrypto.PubkeyToAddress(walletKey.PublicKey),
+		owner: ethcrypto.PubkeyToAddress(owner.PublicKey) }
+
+	contracts.SetWalletCaller(wallet)
+	defer contracts.SetWalletCaller(nil)
+
+	like := &models.ArticleLike{ ArticleDNA: "article" + tests.RandString(5), GroupDNA: "group" }
+
+	// A legacy signature of the owner is accepted for the wallet and
+	// recorded with its wallet envelope
+
+	sigBytes, err := ethcrypto.Sign(ethcrypto.Keccak256([]byte(like.GetSignatureBaseString())), owner)
+	assert.Equal(t, err, nil)
+
+	user, signature, err := contracts.VerifySubmission("metadata", wallet.address.Hex(), like.GetSignatureBaseString(), crypto.LikeMessage(like), hex.EncodeToString(sigBytes), dbi)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, user.Address, wallet.address.Hex())
+	assert.Equal(t, strings.HasPrefix(signature, "04" + hex.EncodeToString(wallet.address.Bytes())), true)
+	assert.Equal(t, wallet.calls, 1)
+
+	// The answer of the wallet is cached
+
+	_, _, err = contracts.VerifySubmission("metadata", wallet.address.Hex(), like.GetSignatureBaseString(), crypto.LikeMessage(like), signature, dbi)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, wallet.calls, 1)
+
+	// Typed data signatures of the wallet carry its nonce and deadline
+
+	domain, err := contracts.GetSignatureDomain("metadata")
+	assert.Equal(t, err, nil)
+
+	nonce := models.GetSignatureNonce(wallet.address.Hex(), dbi) + 1
+	deadline := uint64(time.Now().Unix() + 600)
+
+	sigBytes, err = ethcrypto.Sign(crypto.TypedDataHash(domain, crypto.LikeMessage(like).WithNonce(nonce, deadline)), owner)
+	assert.Equal(t, err, nil)
+
+	typed := &crypto.Signature{ Scheme: crypto.SignatureTypedData, Wallet: wallet.address, Nonce: nonce, Deadline: deadline, Bytes: sigBytes }
+
+	user, _, err = contracts.VerifySubmission("metadata", wallet.address.Hex(), like.GetSignatureBaseString(), crypto.LikeMessage(like), typed.Encode(), dbi)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, user.Address, wallet.address.Hex())
+	assert.Equal(t, models.GetSignatureNonce(wallet.address.Hex(), dbi), nonce)
+
+	// Signatures of someone else are rejected by the wallet
+
+	sigBytes, err = ethcrypto.Sign(ethcrypto.Keccak256([]byte(like.GetSignatureBaseString())), other)
+	assert.Equal(t, err, nil)
+
+	user, _, err = contracts.VerifySubmission("metadata", wallet.address.Hex(), like.GetSignatureBaseString(), crypto.LikeMessage(like), hex.EncodeToString(sigBytes), dbi)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, user.Address != wallet.address.Hex(), true)
+
+	// Addresses without code accept no signature
+
+	otherAddress := ethcrypto.PubkeyToAddress(other.PublicKey)
+
+	valid, err := contracts.VerifyWalletSignature(otherAddress, ethcrypto.Keccak256([]byte("message")), sigBytes, nil, dbi)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, valid, false)
+
+	// Events are verified at their block, answers are cached per block
+
+	digest := ethcrypto.Keccak256([]byte("message" + tests.RandString(5)))
+
+	sigBytes, err = ethcrypto.Sign(digest, owner)
+	assert.Equal(t, err, nil)
+
+	calls := wallet.calls
+
+	valid, err = contracts.VerifyWalletSignature(wallet.address, digest, sigBytes, big.NewInt(100), dbi)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, valid, true)
+	assert.Equal(t, wallet.blockNumber.Int64(), int64(100))
+
+	valid, err = contracts.VerifyWalletSignature(wallet.address, digest, sigBytes, big.NewInt(100), dbi)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, valid, true)
+	assert.Equal(t, wallet.calls, calls + 1)
+
+	valid, err = contracts.VerifyWalletSignature(wallet.address, digest, sigBytes, big.NewInt(101), dbi)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, valid, true)
+	assert.Equal(t, wallet.calls, calls + 2)
+}
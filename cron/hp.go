@@ -0,0 +1,61 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cron
+
+import (
+	"github.com/primasio/primas-node/db"
+	"github.com/primasio/primas-node/models"
+	"log"
+	"strconv"
+	"time"
+)
+
+// SnapshotHP records the HP of every user for the current day. Users
+// already recorded for the day are skipped, so the job can be rerun.
+func SnapshotHP() error {
+
+	dbi := db.GetDb()
+
+	date := time.Now().UTC().Format(models.StatementDateFormat)
+
+	batchSize := 200
+	currentBatchOffset := 0
+
+	recorded := 0
+
+	for {
+		var users []models.User
+
+		in := dbi.Table("users").Order("id asc").Offset(currentBatchOffset).Limit(batchSize)
+		in.Find(&users)
+
+		for _, user := range users {
+			user.SnapshotHP(date, dbi)
+			recorded = recorded + 1
+		}
+
+		if len(users) < batchSize {
+			break
+		}
+
+		currentBatchOffset = currentBatchOffset + batchSize
+	}
+
+	log.Println("hp snapshot " + date + ": " + strconv.Itoa(recorded) + " users")
+
+	return nil
+}
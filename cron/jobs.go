@@ -17,29 +17,36 @@
 package cron
 
 import (
-	"github.com/jasonlvhit/gocron"
 	"github.com/primasio/primas-node/contracts"
-	"log"
+	"github.com/primasio/primas-node/db"
 )
 
-func StartCronJobs () {
+// TriggerInflation inflates the token. The Inflate event then starts the
+// distribution of incentives.
+func TriggerInflation(guard contracts.SubmitGuard) error {
 
-	// Calculate and distribute incentives every day
-	gocron.Every(1).Day().At("20:00").Do(TriggerInflation)
+	tokenContract, err := contracts.GetTokenContract()
+
+	if err != nil {
+		return err
+	}
 
-	<- gocron.Start()
+	return tokenContract.Inflate(guard)
 }
 
-func TriggerInflation() {
+// AssignIncentives grants the incentives of the calculated runs and checks
+// the payments already submitted.
+func AssignIncentives(guard contracts.SubmitGuard) error {
 
-	tokenContract, err := contracts.GetTokenContract()
+	incentiveContract, err := contracts.GetIncentiveContract()
 
 	if err != nil {
-		log.Println(err.Error())
-		return
+		return err
 	}
 
-	if err := tokenContract.Inflate(); err != nil {
-		log.Println(err.Error())
+	if err := incentiveContract.AssignIncentives(guard, db.GetDb()); err != nil {
+		return err
 	}
-}
\ No newline at end of file
+
+	return incentiveContract.ProcessIncentiveBatches(guard, db.GetDb())
+}
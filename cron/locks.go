@@ -0,0 +1,34 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cron
+
+import (
+	"github.com/primasio/primas-node/contracts"
+	"github.com/primasio/primas-node/db"
+)
+
+// UnlockTokens releases the expired token locks on chain.
+func UnlockTokens(guard contracts.SubmitGuard) error {
+
+	tokenContract, err := contracts.GetTokenContract()
+
+	if err != nil {
+		return err
+	}
+
+	return tokenContract.ProcessTokenLocks(guard, db.GetDb())
+}
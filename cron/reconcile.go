@@ -0,0 +1,140 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cron
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/primasio/primas-node/config"
+	"github.com/primasio/primas-node/contracts"
+	"github.com/primasio/primas-node/db"
+	"github.com/primasio/primas-node/models"
+	"log"
+	"math/rand"
+	"strconv"
+	"time"
+)
+
+// ReconcileBalances compares the recorded balance of users with their
+// on-chain balance and writes every difference to the drift report. With
+// reconciliation.sample_size set only that many random users are checked,
+// otherwise all of them are. Drifts are corrected when
+// reconciliation.auto_correct is enabled.
+func ReconcileBalances() error {
+
+	c := config.GetConfig()
+
+	tokenContract, err := contracts.GetTokenContract()
+
+	if err != nil {
+		return err
+	}
+
+	dbi := db.GetDb()
+
+	sampleSize := c.GetInt("reconciliation.sample_size")
+	autoCorrect := c.GetBool("reconciliation.auto_correct")
+
+	batchSize := 200
+	currentBatchOffset := 0
+
+	checked := 0
+	drifted := 0
+	corrected := 0
+
+	for {
+		var users []models.User
+
+		if sampleSize > 0 {
+			users = sampleUsers(sampleSize, dbi)
+		} else {
+			in := dbi.Table("users").Order("id asc").Offset(currentBatchOffset).Limit(batchSize)
+			in.Find(&users)
+		}
+
+		for _, user := range users {
+
+			check, err := tokenContract.CheckBalance(&user, dbi)
+
+			if err != nil {
+				log.Println("balance check failed for " + user.Address + ": " + err.Error())
+				continue
+			}
+
+			checked = checked + 1
+
+			if !check.HasDrift {
+				continue
+			}
+
+			drifted = drifted + 1
+
+			drift := models.NewBalanceDrift(check)
+			dbi.Save(drift)
+
+			if autoCorrect && drift.CorrectBalance(dbi) {
+				corrected = corrected + 1
+			}
+		}
+
+		if sampleSize > 0 || len(users) < batchSize {
+			break
+		}
+
+		currentBatchOffset = currentBatchOffset + batchSize
+	}
+
+	log.Println("balance reconciliation: " + strconv.Itoa(checked) + " checked, " +
+		strconv.Itoa(drifted) + " drifted, " + strconv.Itoa(corrected) + " corrected")
+
+	return nil
+}
+
+// sampleUsers returns up to size distinct users picked at random offsets,
+// which every database supports unlike ordering by a random function.
+func sampleUsers(size int, dbi *gorm.DB) []models.User {
+
+	count := 0
+
+	dbi.Table("users").Count(&count)
+
+	if size > count {
+		size = count
+	}
+
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	offsets := make(map[int]bool)
+
+	for len(offsets) < size {
+		offsets[random.Intn(count)] = true
+	}
+
+	var users []models.User
+
+	for offset := range offsets {
+
+		var user models.User
+
+		dbi.Table("users").Order("id asc").Offset(offset).Limit(1).Find(&user)
+
+		if user.ID != 0 {
+			users = append(users, user)
+		}
+	}
+
+	return users
+}
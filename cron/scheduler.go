@@ -0,0 +1,427 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+	"github.com/jinzhu/gorm"
+	"github.com/primasio/primas-node/config"
+	"github.com/primasio/primas-node/contracts"
+	"github.com/primasio/primas-node/db"
+	"github.com/primasio/primas-node/models"
+)
+
+const schedulerTick = 30 * time.Second
+
+// Failed runs are retried after scheduler.retry_seconds, doubled on each
+// failure, unless the job is scheduled earlier
+const defaultRetryDelay = 60 * time.Second
+const maxRetryDoublings = 10
+
+// Schedule returns the next run time of a job after the given time.
+type Schedule interface {
+	Next(after time.Time) time.Time
+}
+
+// DailySchedule runs a job every day at a time of day in a time zone.
+type DailySchedule struct {
+	Hour     int
+	Minute   int
+	Location *time.Location
+}
+
+func (schedule *DailySchedule) Next(after time.Time) time.Time {
+
+	local := after.In(schedule.Location)
+
+	next := time.Date(local.Year(), local.Month(), local.Day(), schedule.Hour, schedule.Minute, 0, 0, schedule.Location)
+
+	if !next.After(local) {
+		next = next.AddDate(0, 0, 1)
+	}
+
+	return next
+}
+
+// IntervalSchedule runs a job at a fixed interval.
+type IntervalSchedule struct {
+	Interval time.Duration
+}
+
+func (schedule *IntervalSchedule) Next(after time.Time) time.Time {
+	return after.Add(schedule.Interval)
+}
+
+// Job runs with the guard of the scheduler lock, which it checks before
+// each transaction it submits.
+type Job struct {
+	Name     string
+	Schedule Schedule
+	Run      func(guard contracts.SubmitGuard) error
+}
+
+var jobs = map[string]func(guard contracts.SubmitGuard) error{
+	"inflation":          TriggerInflation,
+	"reconcile_balances": withoutTransactions(ReconcileBalances),
+	"hp_snapshot":        withoutTransactions(SnapshotHP),
+	"unlock_tokens":      UnlockTokens,
+	"assign_incentives":  AssignIncentives,
+}
+
+func withoutTransactions(run func() error) func(guard contracts.SubmitGuard) error {
+	return func(guard contracts.SubmitGuard) error {
+		return run()
+	}
+}
+
+// LoadJobs reads the schedules of scheduler.jobs. A job takes either
+// "daily_at" (HH:MM) with an optional "time_zone", falling back to
+// scheduler.time_zone, or "every" as a duration such as "6h". Jobs without
+// a schedule are not run.
+func LoadJobs() ([]*Job, error) {
+
+	c := config.GetConfig()
+
+	defaultZone := c.GetString("scheduler.time_zone")
+
+	var loaded []*Job
+
+	for name, run := range jobs {
+
+		key := "scheduler.jobs." + name
+
+		if !c.IsSet(key) {
+			continue
+		}
+
+		job := &Job{ Name: name, Run: run }
+
+		if dailyAt := c.GetString(key + ".daily_at"); dailyAt != "" {
+
+			zone := c.GetString(key + ".time_zone")
+
+			if zone == "" {
+				zone = defaultZone
+			}
+
+			schedule, err := parseDailySchedule(dailyAt, zone)
+
+			if err != nil {
+				return nil, errors.New("job " + name + ": " + err.Error())
+			}
+
+			job.Schedule = schedule
+
+		} else if every := c.GetString(key + ".every"); every != "" {
+
+			interval, err := time.ParseDuration(every)
+
+			if err != nil || interval <= 0 {
+				return nil, errors.New("job " + name + ": invalid interval " + every)
+			}
+
+			job.Schedule = &IntervalSchedule{ Interval: interval }
+
+		} else {
+			return nil, errors.New("job " + name + " has no schedule")
+		}
+
+		loaded = append(loaded, job)
+	}
+
+	return loaded, nil
+}
+
+func parseDailySchedule(dailyAt, zone string) (*DailySchedule, error) {
+
+	location, err := time.LoadLocation(zone)
+
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(dailyAt, ":")
+
+	if len(parts) != 2 {
+		return nil, errors.New("invalid time of day " + dailyAt)
+	}
+
+	hour, err := strconv.Atoi(parts[0])
+
+	if err != nil || hour < 0 || hour > 23 {
+		return nil, errors.New("invalid time of day " + dailyAt)
+	}
+
+	minute, err := strconv.Atoi(parts[1])
+
+	if err != nil || minute < 0 || minute > 59 {
+		return nil, errors.New("invalid time of day " + dailyAt)
+	}
+
+	return &DailySchedule{ Hour: hour, Minute: minute, Location: location }, nil
+}
+
+// StartScheduler runs the configured jobs. Every node instance runs the
+// scheduler but only the one holding the scheduler lock runs jobs, so
+// that the token is inflated once however many instances are running.
+func StartScheduler() error {
+
+	loaded, err := LoadJobs()
+
+	if err != nil {
+		return err
+	}
+
+	owner := instanceName()
+
+	ttl := time.Duration(config.GetConfig().GetInt64("scheduler.lock_ttl_seconds")) * time.Second
+
+	if ttl < 2 * schedulerTick {
+		ttl = 2 * schedulerTick
+	}
+
+	log.Println("scheduler started as " + owner)
+
+	ticker := time.NewTicker(schedulerTick)
+
+	for {
+		dbi := db.GetDb()
+
+		if models.AcquireSchedulerLock(owner, ttl, dbi) {
+			for _, job := range loaded {
+				runIfDue(job, owner, ttl, dbi)
+			}
+		}
+
+		<- ticker.C
+	}
+}
+
+// runIfDue runs the job when it is due or was triggered manually. The
+// next run time is stored before the job runs so that it is not run again
+// by another instance taking over the lock. A failed run is retried with
+// backoff.
+func runIfDue(job *Job, owner string, ttl time.Duration, dbi *gorm.DB) {
+
+	// A previous job may have run longer than the lock
+	if !models.HoldsSchedulerLock(owner, dbi) {
+		return
+	}
+
+	now := time.Now()
+
+	tx := dbi.Begin()
+
+	state := &models.SchedulerJob{}
+
+	tx.Set("gorm:query_option", "FOR UPDATE").Where(&models.SchedulerJob{ Name: job.Name }).First(state)
+
+	if state.ID == 0 {
+		state.Name = job.Name
+	}
+
+	trigger := ""
+
+	if state.TriggerRequested {
+		trigger = models.JobTriggerManual
+	} else if !state.Paused && state.NextRunAt != 0 && uint(now.Unix()) >= state.NextRunAt {
+		trigger = models.JobTriggerSchedule
+	}
+
+	if trigger == "" && state.NextRunAt != 0 {
+		tx.Rollback()
+		return
+	}
+
+	state.TriggerRequested = false
+	state.NextRunAt = uint(job.Schedule.Next(now).Unix())
+
+	if trigger != "" {
+		state.LastRunAt = uint(now.Unix())
+	}
+
+	tx.Save(state)
+	tx.Commit()
+
+	if trigger == "" {
+		// First time the job is seen, only schedule it
+		return
+	}
+
+	run := &models.JobRun{}
+	run.JobName = job.Name
+	run.Owner = owner
+	run.Trigger = trigger
+	run.StartedAt = uint(now.Unix())
+	run.Status = models.JobRunRunning
+
+	dbi.Save(run)
+
+	lease := startLease(owner, ttl, dbi)
+
+	err := runJob(job, lease.Check)
+
+	lease.stop()
+
+	run.FinishedAt = uint(time.Now().Unix())
+
+	if err != nil {
+		run.Status = models.JobRunFailed
+		run.Error = err.Error()
+		log.Println("job " + job.Name + " failed: " + err.Error())
+	} else {
+		run.Status = models.JobRunSucceeded
+	}
+
+	dbi.Save(run)
+
+	recordOutcome(job, err, dbi)
+}
+
+// recordOutcome schedules the retry of a failed run, the delay doubling
+// with each consecutive failure. A retry later than the next scheduled run
+// is left to that run.
+func recordOutcome(job *Job, err error, dbi *gorm.DB) {
+
+	tx := dbi.Begin()
+
+	state := &models.SchedulerJob{}
+
+	tx.Set("gorm:query_option", "FOR UPDATE").Where(&models.SchedulerJob{ Name: job.Name }).First(state)
+
+	if state.ID == 0 {
+		tx.Rollback()
+		return
+	}
+
+	if err == nil {
+		state.Failures = 0
+		tx.Save(state)
+		tx.Commit()
+		return
+	}
+
+	state.Failures = state.Failures + 1
+
+	retryAt := uint(time.Now().Add(retryDelay(state.Failures)).Unix())
+
+	if retryAt < state.NextRunAt {
+		state.NextRunAt = retryAt
+	}
+
+	log.Println("job " + job.Name + " retried at " + time.Unix(int64(state.NextRunAt), 0).String())
+
+	tx.Save(state)
+	tx.Commit()
+}
+
+func retryDelay(failures uint) time.Duration {
+
+	delay := time.Duration(config.GetConfig().GetInt64("scheduler.retry_seconds")) * time.Second
+
+	if delay <= 0 {
+		delay = defaultRetryDelay
+	}
+
+	doublings := failures - 1
+
+	if doublings > maxRetryDoublings {
+		doublings = maxRetryDoublings
+	}
+
+	return delay << doublings
+}
+
+// lease keeps the scheduler lock while a job runs. It is renewed in the
+// background, and is lost for good once a renewal fails so that a job
+// outliving the lock does not submit transactions next to the instance
+// that took it over.
+type lease struct {
+	owner string
+	dbi   *gorm.DB
+	lost  int32
+	done  chan struct{}
+}
+
+func startLease(owner string, ttl time.Duration, dbi *gorm.DB) *lease {
+
+	l := &lease{ owner: owner, dbi: dbi, done: make(chan struct{}) }
+
+	go func() {
+
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <- l.done:
+				return
+			case <- ticker.C:
+				if !models.AcquireSchedulerLock(owner, ttl, dbi) {
+					atomic.StoreInt32(&l.lost, 1)
+					log.Println("scheduler lock lost by " + owner)
+					return
+				}
+			}
+		}
+	}()
+
+	return l
+}
+
+func (l *lease) stop() {
+	close(l.done)
+}
+
+// Check returns an error unless the lock is still held.
+func (l *lease) Check() error {
+
+	if atomic.LoadInt32(&l.lost) == 1 || !models.HoldsSchedulerLock(l.owner, l.dbi) {
+		return errors.New("scheduler lock lost by " + l.owner)
+	}
+
+	return nil
+}
+
+func runJob(job *Job, guard contracts.SubmitGuard) (err error) {
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return job.Run(guard)
+}
+
+func instanceName() string {
+
+	host, err := os.Hostname()
+
+	if err != nil {
+		host = "unknown"
+	}
+
+	return host + ":" + strconv.Itoa(os.Getpid())
+}
@@ -0,0 +1,41 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cron_test
+
+import (
+	"testing"
+	"time"
+	"github.com/magiconair/properties/assert"
+	"github.com/primasio/primas-node/cron"
+)
+
+func TestDailySchedule (t *testing.T) {
+
+	location := time.FixedZone("UTC+8", 8 * 3600)
+
+	schedule := &cron.DailySchedule{ Hour: 20, Minute: 0, Location: location }
+
+	// 10:00 UTC is 18:00 UTC+8, the job runs at 20:00 UTC+8 the same day
+
+	after := time.Date(2018, 1, 10, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, schedule.Next(after).UTC(), time.Date(2018, 1, 10, 12, 0, 0, 0, time.UTC))
+
+	// At 20:00 UTC+8 the next run is the day after
+
+	after = time.Date(2018, 1, 10, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, schedule.Next(after).UTC(), time.Date(2018, 1, 11, 12, 0, 0, 0, time.UTC))
+}
@@ -18,29 +18,33 @@ package crypto
 
 import (
 	"github.com/primasio/primas-node/models"
-	"encoding/hex"
-	"github.com/ethereum/go-ethereum/crypto/secp256k1"
-	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/common"
 )
 
+// ExtractUserFromSignature recovers the signer of the Keccak256 of msg.
+// Signatures may be 0x prefixed and have a recovery ID of 27 or 28.
 func ExtractUserFromSignature(msg, signature string) (*models.User, error) {
 
-	msgBytes := crypto.Keccak256([]byte(msg))
-
-	sigBytes, err := hex.DecodeString(signature)
+	addr, _, err := RecoverSigner(msg, nil, nil, signature, true)
 
 	if err != nil {
 		return nil, err
 	}
 
-	publicKey, err := secp256k1.RecoverPubkey(msgBytes, sigBytes)
+	return &models.User{ Address: addr.Hex() }, nil
+}
+
+// ExtractClaimedUserFromSignature recovers the signer of msg for a
+// signature submitted on behalf of claimedAddress, trying the
+// personal_sign hash as well. The user contract verifies these signatures
+// itself, typed data signatures are not accepted.
+func ExtractClaimedUserFromSignature(msg, signature, claimedAddress string) (*models.User, error) {
+
+	addr, _, err := RecoverClaimedSigner(common.HexToAddress(claimedAddress), msg, nil, nil, signature, true)
 
 	if err != nil {
 		return nil, err
 	}
 
-	pk := crypto.ToECDSAPub(publicKey)
-	addr := crypto.PubkeyToAddress(*pk)
-
 	return &models.User{ Address: addr.Hex() }, nil
 }
@@ -0,0 +1,81 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package crypto
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/primasio/primas-node/models"
+)
+
+// Typed messages of the actions signed by users. Joining and leaving a
+// group sign different types so that one cannot be replayed as the other.
+
+func ArticleMessage(article *models.Article) *TypedMessage {
+	return &TypedMessage{ PrimaryType: "Article", Fields: []TypedField{
+		{ Name: "title", Type: "string", Value: article.Title },
+		{ Name: "contentHash", Type: "string", Value: article.ContentHash },
+		{ Name: "license", Type: "string", Value: article.License },
+	}}
+}
+
+func GroupMessage(group *models.Group) *TypedMessage {
+	return &TypedMessage{ PrimaryType: "Group", Fields: []TypedField{
+		{ Name: "title", Type: "string", Value: group.Title },
+		{ Name: "description", Type: "string", Value: group.Description },
+	}}
+}
+
+func JoinGroupMessage(member *models.GroupMember) *TypedMessage {
+	return &TypedMessage{ PrimaryType: "JoinGroup", Fields: []TypedField{
+		{ Name: "groupDNA", Type: "string", Value: member.GroupDNA },
+	}}
+}
+
+func LeaveGroupMessage(member *models.GroupMember) *TypedMessage {
+	return &TypedMessage{ PrimaryType: "LeaveGroup", Fields: []TypedField{
+		{ Name: "groupDNA", Type: "string", Value: member.GroupDNA },
+	}}
+}
+
+func RemoveGroupMemberMessage(member *models.GroupMember) *TypedMessage {
+	return &TypedMessage{ PrimaryType: "RemoveGroupMember", Fields: []TypedField{
+		{ Name: "groupDNA", Type: "string", Value: member.GroupDNA },
+		{ Name: "member", Type: "address", Value: common.HexToAddress(member.MemberAddress) },
+	}}
+}
+
+func LikeMessage(like *models.ArticleLike) *TypedMessage {
+	return &TypedMessage{ PrimaryType: "Like", Fields: []TypedField{
+		{ Name: "articleDNA", Type: "string", Value: like.ArticleDNA },
+		{ Name: "groupDNA", Type: "string", Value: like.GroupDNA },
+	}}
+}
+
+func CommentMessage(comment *models.ArticleComment) *TypedMessage {
+	return &TypedMessage{ PrimaryType: "Comment", Fields: []TypedField{
+		{ Name: "articleDNA", Type: "string", Value: comment.ArticleDNA },
+		{ Name: "groupDNA", Type: "string", Value: comment.GroupDNA },
+		{ Name: "contentHash", Type: "string", Value: comment.ContentHash },
+	}}
+}
+
+func ShareMessage(shareBatch *models.ArticleShareBatch) *TypedMessage {
+	return &TypedMessage{ PrimaryType: "Share", Fields: []TypedField{
+		{ Name: "articleDNA", Type: "string", Value: shareBatch.ArticleDNA },
+		{ Name: "groupDNAs", Type: "string[]", Value: shareBatch.GroupDNAs },
+	}}
+}
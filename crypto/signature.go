@@ -0,0 +1,327 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package crypto
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/secp256k1"
+)
+
+// Signature schemes
+const SignatureLegacy = 1
+const SignatureTypedData = 2
+const SignaturePersonal = 3
+
+// Signatures other than legacy ones are prefixed with a version byte so
+// that nodes synchronizing them from Blockchain know how they were made:
+//
+//   personal_sign:     0x01 | r | s | v
+//   typed data:        0x02 | nonce (8 bytes) | deadline (8 bytes) | r | s | v
+//   wallet typed data: 0x03 | wallet (20 bytes) | nonce | deadline | wallet signature
+//   wallet legacy:     0x04 | wallet (20 bytes) | wallet signature
+//
+// Smart contract wallets (EIP-1271) sign in a format of their own, such as
+// the concatenated signatures of multisig owners, so their signatures name
+// the wallet which verifies them.
+const personalSignaturePrefix = 0x01
+const personalSignatureLength = 1 + 65
+const typedSignaturePrefix = 0x02
+const typedSignatureLength = 1 + 8 + 8 + 65
+const walletTypedSignaturePrefix = 0x03
+const walletTypedSignatureHeader = 1 + 20 + 8 + 8
+const walletSignaturePrefix = 0x04
+const walletSignatureHeader = 1 + 20
+
+// Signature is a decoded signature. Legacy and personal signatures have no
+// nonce and deadline. Wallet is set for signatures of a smart contract
+// wallet, Bytes is then the wallet signature as is.
+type Signature struct {
+	Scheme   int
+	Nonce    uint64
+	Deadline uint64
+	Wallet   common.Address
+	Bytes    []byte
+}
+
+// DecodeSignature decodes a hex signature with or without the 0x prefix
+// added by wallets.
+func DecodeSignature(signature string) ([]byte, error) {
+
+	if strings.HasPrefix(signature, "0x") || strings.HasPrefix(signature, "0X") {
+		signature = signature[2:]
+	}
+
+	return hex.DecodeString(signature)
+}
+
+// ParseSignature decodes a signature of any scheme. The recovery ID is
+// normalized to 0 or 1, wallets produce 27 or 28.
+func ParseSignature(signature string) (*Signature, error) {
+
+	sigBytes, err := DecodeSignature(signature)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var sig *Signature
+
+	switch {
+	case len(sigBytes) == 65:
+		sig = &Signature{ Scheme: SignatureLegacy, Bytes: sigBytes }
+	case len(sigBytes) == personalSignatureLength && sigBytes[0] == personalSignaturePrefix:
+		sig = &Signature{ Scheme: SignaturePersonal, Bytes: sigBytes[1:] }
+	case len(sigBytes) == typedSignatureLength && sigBytes[0] == typedSignaturePrefix:
+		sig = &Signature{
+			Scheme: SignatureTypedData,
+			Nonce: binary.BigEndian.Uint64(sigBytes[1:9]),
+			Deadline: binary.BigEndian.Uint64(sigBytes[9:17]),
+			Bytes: sigBytes[17:] }
+	case len(sigBytes) > walletTypedSignatureHeader && sigBytes[0] == walletTypedSignaturePrefix:
+		return &Signature{
+			Scheme: SignatureTypedData,
+			Wallet: common.BytesToAddress(sigBytes[1:21]),
+			Nonce: binary.BigEndian.Uint64(sigBytes[21:29]),
+			Deadline: binary.BigEndian.Uint64(sigBytes[29:37]),
+			Bytes: sigBytes[37:] }, nil
+	case len(sigBytes) > walletSignatureHeader && sigBytes[0] == walletSignaturePrefix:
+		return &Signature{
+			Scheme: SignatureLegacy,
+			Wallet: common.BytesToAddress(sigBytes[1:21]),
+			Bytes: sigBytes[21:] }, nil
+	default:
+		return nil, errors.New("invalid signature")
+	}
+
+	sig.Bytes = normalizeRecoveryID(sig.Bytes)
+
+	return sig, nil
+}
+
+// ContractSignature returns a signature verified by a contract against the
+// Keccak256 of the bare message, such as the user contract: 65 bytes with a
+// recovery ID of 0 or 1. Personal, typed data and wallet signatures are
+// rejected since the contract would not verify them.
+func ContractSignature(signature string) ([]byte, error) {
+
+	sig, err := ParseSignature(signature)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if sig.Scheme != SignatureLegacy || sig.IsWalletSignature() {
+		return nil, errors.New("only signatures of the Keccak256 of the message are accepted")
+	}
+
+	return sig.Bytes, nil
+}
+
+// NewWalletSignature decodes a signature submitted on behalf of the smart
+// contract wallet. Signatures without an envelope for the wallet are taken
+// as its signature of the legacy message, they may start like an envelope.
+func NewWalletSignature(wallet common.Address, signature string) (*Signature, error) {
+
+	if sig, err := ParseSignature(signature); err == nil && sig.Wallet == wallet {
+		return sig, nil
+	}
+
+	sigBytes, err := DecodeSignature(signature)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sigBytes) == 0 {
+		return nil, errors.New("invalid signature")
+	}
+
+	return &Signature{ Scheme: SignatureLegacy, Wallet: wallet, Bytes: sigBytes }, nil
+}
+
+// IsWalletSignature reports whether the signature is verified by a smart
+// contract wallet rather than recovered.
+func (sig *Signature) IsWalletSignature() bool {
+	return sig.Wallet != common.Address{}
+}
+
+// Encode returns the hex encoding of the signature recorded on Blockchain.
+func (sig *Signature) Encode() string {
+
+	if sig.IsWalletSignature() {
+		return sig.encodeWallet()
+	}
+
+	switch sig.Scheme {
+	case SignaturePersonal:
+		return hex.EncodeToString(append([]byte{personalSignaturePrefix}, sig.Bytes...))
+	case SignatureTypedData:
+		return EncodeTypedSignature(sig.Nonce, sig.Deadline, sig.Bytes)
+	default:
+		return hex.EncodeToString(sig.Bytes)
+	}
+}
+
+func (sig *Signature) encodeWallet() string {
+
+	if sig.Scheme != SignatureTypedData {
+		encoded := append([]byte{walletSignaturePrefix}, sig.Wallet.Bytes()...)
+		return hex.EncodeToString(append(encoded, sig.Bytes...))
+	}
+
+	encoded := make([]byte, walletTypedSignatureHeader, walletTypedSignatureHeader + len(sig.Bytes))
+	encoded[0] = walletTypedSignaturePrefix
+	copy(encoded[1:21], sig.Wallet.Bytes())
+	binary.BigEndian.PutUint64(encoded[21:29], sig.Nonce)
+	binary.BigEndian.PutUint64(encoded[29:37], sig.Deadline)
+
+	return hex.EncodeToString(append(encoded, sig.Bytes...))
+}
+
+// EncodeTypedSignature returns the hex encoding of a typed data signature
+// with its nonce and deadline.
+func EncodeTypedSignature(nonce uint64, deadline uint64, sigBytes []byte) string {
+
+	encoded := make([]byte, 17, typedSignatureLength)
+	encoded[0] = typedSignaturePrefix
+	binary.BigEndian.PutUint64(encoded[1:9], nonce)
+	binary.BigEndian.PutUint64(encoded[9:17], deadline)
+
+	return hex.EncodeToString(append(encoded, sigBytes...))
+}
+
+// PersonalMessageHash returns the hash signed by personal_sign.
+func PersonalMessageHash(msg string) []byte {
+	return crypto.Keccak256([]byte("\x19Ethereum Signed Message:\n" + strconv.Itoa(len(msg)) + msg))
+}
+
+// RecoverSigner returns the signer of an action. Legacy signatures are
+// checked against the Keccak256 of legacyMessage, personal signatures
+// against its personal_sign hash and typed data signatures against the
+// EIP-712 hash of message in domain.
+func RecoverSigner(legacyMessage string, message *TypedMessage, domain *Domain, signature string, allowLegacy bool) (common.Address, *Signature, error) {
+
+	sig, err := ParseSignature(signature)
+
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+
+	address, err := sig.Recover(legacyMessage, message, domain, allowLegacy)
+
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+
+	return address, sig, nil
+}
+
+// RecoverClaimedSigner is RecoverSigner for a signature submitted on
+// behalf of claimed. A signature without version byte may come from
+// personal_sign, it is then tried against the prefixed hash and marked
+// personal so that it is recorded unambiguously.
+func RecoverClaimedSigner(claimed common.Address, legacyMessage string, message *TypedMessage, domain *Domain, signature string, allowLegacy bool) (common.Address, *Signature, error) {
+
+	address, sig, err := RecoverSigner(legacyMessage, message, domain, signature, allowLegacy)
+
+	if err != nil || address == claimed || sig.Scheme != SignatureLegacy {
+		return address, sig, err
+	}
+
+	personal := &Signature{ Scheme: SignaturePersonal, Bytes: sig.Bytes }
+
+	if personalAddress, err := personal.Recover(legacyMessage, message, domain, allowLegacy); err == nil && personalAddress == claimed {
+		return personalAddress, personal, nil
+	}
+
+	return address, sig, nil
+}
+
+// Recover returns the signer of the digest of the signature. Signatures
+// of smart contract wallets cannot be recovered, the wallet verifies them.
+func (sig *Signature) Recover(legacyMessage string, message *TypedMessage, domain *Domain, allowLegacy bool) (common.Address, error) {
+
+	if sig.IsWalletSignature() {
+		return common.Address{}, errors.New("wallet signatures are verified by the wallet")
+	}
+
+	digest, err := sig.Digest(legacyMessage, message, domain, allowLegacy)
+
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	return recoverAddress(digest, sig.Bytes)
+}
+
+// Digest returns the hash signed according to the scheme of the signature.
+func (sig *Signature) Digest(legacyMessage string, message *TypedMessage, domain *Domain, allowLegacy bool) ([]byte, error) {
+
+	switch sig.Scheme {
+	case SignatureLegacy, SignaturePersonal:
+
+		if !allowLegacy {
+			return nil, errors.New("legacy signatures are not accepted")
+		}
+
+		if sig.Scheme == SignaturePersonal {
+			return PersonalMessageHash(legacyMessage), nil
+		}
+
+		return crypto.Keccak256([]byte(legacyMessage)), nil
+
+	default:
+
+		if message == nil || domain == nil {
+			return nil, errors.New("typed data signatures are not accepted")
+		}
+
+		return TypedDataHash(domain, message.WithNonce(sig.Nonce, sig.Deadline)), nil
+	}
+}
+
+func recoverAddress(digest []byte, sigBytes []byte) (common.Address, error) {
+
+	publicKey, err := secp256k1.RecoverPubkey(digest, sigBytes)
+
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	pk := crypto.ToECDSAPub(publicKey)
+
+	return crypto.PubkeyToAddress(*pk), nil
+}
+
+func normalizeRecoveryID(sigBytes []byte) []byte {
+
+	if sigBytes[64] < 27 {
+		return sigBytes
+	}
+
+	normalized := make([]byte, 65)
+	copy(normalized, sigBytes)
+	normalized[64] = normalized[64] - 27
+
+	return normalized
+}
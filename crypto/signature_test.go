@@ -0,0 +1,190 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package crypto_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"testing"
+	"github.com/ethereum/go-ethereum/common"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/magiconair/properties/assert"
+	"github.com/primasio/primas-node/crypto"
+	"github.com/primasio/primas-node/models"
+)
+
+func TestTypedDataHash (t *testing.T) {
+
+	domain := crypto.NewDomain(big.NewInt(3), common.HexToAddress("0x1234567890123456789012345678901234567890"))
+
+	member := &models.GroupMember{ GroupDNA: "DNA1", MemberAddress: "0xabcdefabcdefabcdefabcdefabcdefabcdefabcd" }
+
+	message := crypto.RemoveGroupMemberMessage(member).WithNonce(7, 1700000000)
+
+	// Hash computed by eth_signTypedData_v4
+	assert.Equal(t, hex.EncodeToString(crypto.TypedDataHash(domain, message)),
+		"0bb9617cada4343d0515371d0840e8ced59bca98d540491b8ef71ae13877898e")
+}
+
+func TestTypedDataHashArray (t *testing.T) {
+
+	domain := crypto.NewDomain(big.NewInt(3), common.HexToAddress("0x1234567890123456789012345678901234567890"))
+
+	shareBatch := &models.ArticleShareBatch{ ArticleDNA: "A1", GroupDNAs: []string{"G1", "G2"} }
+
+	message := crypto.ShareMessage(shareBatch).WithNonce(7, 1700000000)
+
+	// Hash computed by eth_signTypedData_v4, group DNAs as string[]
+	assert.Equal(t, hex.EncodeToString(crypto.TypedDataHash(domain, message)),
+		"c3d01b3e94caf697a31826abc872e9103ffc1e66215d504d5b74d5f7da0b0629")
+}
+
+func TestRecoverSigner (t *testing.T) {
+
+	key, err := ethcrypto.GenerateKey()
+	assert.Equal(t, err, nil)
+
+	address := ethcrypto.PubkeyToAddress(key.PublicKey)
+
+	domain := crypto.NewDomain(big.NewInt(3), common.HexToAddress("0x1234567890123456789012345678901234567890"))
+
+	like := &models.ArticleLike{ ArticleDNA: "article", GroupDNA: "group" }
+
+	sigBytes, err := ethcrypto.Sign(crypto.TypedDataHash(domain, crypto.LikeMessage(like).WithNonce(1, 1700000000)), key)
+	assert.Equal(t, err, nil)
+
+	signature := crypto.EncodeTypedSignature(1, 1700000000, sigBytes)
+
+	signer, sig, err := crypto.RecoverSigner(like.GetSignatureBaseString(), crypto.LikeMessage(like), domain, signature, false)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, signer, address)
+	assert.Equal(t, sig.Nonce, uint64(1))
+
+	// The signature does not verify for another group
+
+	other := &models.ArticleLike{ ArticleDNA: "article", GroupDNA: "other" }
+
+	signer, _, err = crypto.RecoverSigner(other.GetSignatureBaseString(), crypto.LikeMessage(other), domain, signature, false)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, signer != address, true)
+
+	// Legacy signatures are refused unless allowed
+
+	legacy, err := ethcrypto.Sign(ethcrypto.Keccak256([]byte(like.GetSignatureBaseString())), key)
+	assert.Equal(t, err, nil)
+
+	_, _, err = crypto.RecoverSigner(like.GetSignatureBaseString(), crypto.LikeMessage(like), domain, hex.EncodeToString(legacy), false)
+	assert.Equal(t, err != nil, true)
+
+	signer, _, err = crypto.RecoverSigner(like.GetSignatureBaseString(), crypto.LikeMessage(like), domain, hex.EncodeToString(legacy), true)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, signer, address)
+}
+
+func TestRecoverPersonalSignature (t *testing.T) {
+
+	key, err := ethcrypto.GenerateKey()
+	assert.Equal(t, err, nil)
+
+	address := ethcrypto.PubkeyToAddress(key.PublicKey)
+
+	msg := "article" + "group"
+
+	sigBytes, err := ethcrypto.Sign(crypto.PersonalMessageHash(msg), key)
+	assert.Equal(t, err, nil)
+
+	// Wallets return V as 27 or 28 and 0x prefixed hex
+	sigBytes[64] = sigBytes[64] + 27
+	signature := "0x" + hex.EncodeToString(sigBytes)
+
+	signer, sig, err := crypto.RecoverClaimedSigner(address, msg, nil, nil, signature, true)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, signer, address)
+	assert.Equal(t, sig.Scheme, crypto.SignaturePersonal)
+
+	// The recorded signature is recovered without the claimed address
+	signer, _, err = crypto.RecoverSigner(msg, nil, nil, sig.Encode(), true)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, signer, address)
+}
+
+func TestWalletSignatureEnvelope (t *testing.T) {
+
+	wallet := common.HexToAddress("0x1234567890123456789012345678901234567890")
+
+	// Multisig wallets concatenate the signatures of their owners
+	walletBytes := bytes.Repeat([]byte{0x03}, 130)
+
+	sig, err := crypto.NewWalletSignature(wallet, "0x" + hex.EncodeToString(walletBytes))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sig.IsWalletSignature(), true)
+	assert.Equal(t, sig.Scheme, crypto.SignatureLegacy)
+
+	parsed, err := crypto.ParseSignature(sig.Encode())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, parsed.Wallet, wallet)
+	assert.Equal(t, parsed.Bytes, walletBytes)
+
+	typed := &crypto.Signature{ Scheme: crypto.SignatureTypedData, Wallet: wallet, Nonce: 3, Deadline: 1700000000, Bytes: walletBytes }
+
+	parsed, err = crypto.ParseSignature(typed.Encode())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, parsed.Wallet, wallet)
+	assert.Equal(t, parsed.Scheme, crypto.SignatureTypedData)
+	assert.Equal(t, parsed.Nonce, uint64(3))
+	assert.Equal(t, parsed.Deadline, uint64(1700000000))
+	assert.Equal(t, parsed.Bytes, walletBytes)
+
+	// For another wallet the envelope is taken as a bare signature
+
+	other := common.HexToAddress("0x01")
+
+	parsed, err = crypto.NewWalletSignature(other, typed.Encode())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, parsed.Wallet, other)
+	assert.Equal(t, parsed.Scheme, crypto.SignatureLegacy)
+}
+
+func TestContractSignature (t *testing.T) {
+
+	key, err := ethcrypto.GenerateKey()
+	assert.Equal(t, err, nil)
+
+	sigBytes, err := ethcrypto.Sign(ethcrypto.Keccak256([]byte("1520000000")), key)
+	assert.Equal(t, err, nil)
+
+	// Wallet encodings are forwarded with a recovery ID of 0 or 1
+
+	walletBytes := make([]byte, 65)
+	copy(walletBytes, sigBytes)
+	walletBytes[64] = walletBytes[64] + 27
+
+	forwarded, err := crypto.ContractSignature("0x" + hex.EncodeToString(walletBytes))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, forwarded, sigBytes)
+
+	// Personal and typed data signatures are not verified by contracts
+
+	personal := &crypto.Signature{ Scheme: crypto.SignaturePersonal, Bytes: sigBytes }
+
+	_, err = crypto.ContractSignature(personal.Encode())
+	assert.Equal(t, err != nil, true)
+
+	_, err = crypto.ContractSignature(crypto.EncodeTypedSignature(1, 1700000000, sigBytes))
+	assert.Equal(t, err != nil, true)
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package crypto
+
+import (
+	"math/big"
+	"strings"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// EIP-712 domain of the node
+const DomainName = "Primas"
+const DomainVersion = "1"
+
+const domainType = "EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)"
+
+// Domain separates the signatures of one chain and contract from the
+// others.
+type Domain struct {
+	Name              string
+	Version           string
+	ChainID           *big.Int
+	VerifyingContract common.Address
+}
+
+// TypedField is a member of a typed message. Supported types are string,
+// string[], address, uint64 and uint256.
+type TypedField struct {
+	Name  string
+	Type  string
+	Value interface{}
+}
+
+// TypedMessage is the EIP-712 struct signed for an action. The nonce and
+// the deadline of the signature are appended to its fields when hashed.
+type TypedMessage struct {
+	PrimaryType string
+	Fields      []TypedField
+}
+
+func NewDomain(chainID *big.Int, verifyingContract common.Address) *Domain {
+	return &Domain{ Name: DomainName, Version: DomainVersion, ChainID: chainID, VerifyingContract: verifyingContract }
+}
+
+func (domain *Domain) Separator() []byte {
+	return crypto.Keccak256(
+		crypto.Keccak256([]byte(domainType)),
+		crypto.Keccak256([]byte(domain.Name)),
+		crypto.Keccak256([]byte(domain.Version)),
+		common.LeftPadBytes(domain.ChainID.Bytes(), 32),
+		common.LeftPadBytes(domain.VerifyingContract.Bytes(), 32))
+}
+
+// WithNonce returns the message with the nonce and deadline fields.
+func (message *TypedMessage) WithNonce(nonce uint64, deadline uint64) *TypedMessage {
+
+	fields := make([]TypedField, 0, len(message.Fields) + 2)
+	fields = append(fields, message.Fields...)
+	fields = append(fields, TypedField{ Name: "nonce", Type: "uint64", Value: nonce })
+	fields = append(fields, TypedField{ Name: "deadline", Type: "uint64", Value: deadline })
+
+	return &TypedMessage{ PrimaryType: message.PrimaryType, Fields: fields }
+}
+
+// EncodeType returns the type string, such as "Like(string articleDNA,...)".
+func (message *TypedMessage) EncodeType() string {
+
+	var members []string
+
+	for _, field := range message.Fields {
+		members = append(members, field.Type + " " + field.Name)
+	}
+
+	return message.PrimaryType + "(" + strings.Join(members, ",") + ")"
+}
+
+// Hash returns the hashStruct of the message.
+func (message *TypedMessage) Hash() []byte {
+
+	data := [][]byte{ crypto.Keccak256([]byte(message.EncodeType())) }
+
+	for _, field := range message.Fields {
+		data = append(data, encodeValue(field))
+	}
+
+	return crypto.Keccak256(data...)
+}
+
+// TypedDataHash returns the digest signed by eth_signTypedData.
+func TypedDataHash(domain *Domain, message *TypedMessage) []byte {
+	return crypto.Keccak256([]byte{0x19, 0x01}, domain.Separator(), message.Hash())
+}
+
+func encodeValue(field TypedField) []byte {
+
+	switch value := field.Value.(type) {
+	case string:
+		return crypto.Keccak256([]byte(value))
+	case []string:
+		// Arrays hash the concatenation of the hashes of their elements
+		var hashes [][]byte
+		for _, item := range value {
+			hashes = append(hashes, crypto.Keccak256([]byte(item)))
+		}
+		return crypto.Keccak256(hashes...)
+	case common.Address:
+		return common.LeftPadBytes(value.Bytes(), 32)
+	case uint64:
+		return common.LeftPadBytes(new(big.Int).SetUint64(value).Bytes(), 32)
+	case *big.Int:
+		return common.LeftPadBytes(value.Bytes(), 32)
+	default:
+		panic("unsupported typed data field " + field.Name)
+	}
+}
@@ -46,7 +46,7 @@ func (ctrl ArticleController) Publish(c *gin.Context) {
 
 		// Validate author signature
 
-		author, err := crypto.ExtractUserFromSignature(article.GetSignatureBaseString(), article.Signature)
+		author, signature, err := contracts.VerifySubmission("metadata", article.UserAddress, article.GetSignatureBaseString(), crypto.ArticleMessage(&article), article.Signature, dbInstance)
 
 		if err != nil {
 			dbInstance.Rollback()
@@ -60,6 +60,8 @@ func (ctrl ArticleController) Publish(c *gin.Context) {
 			return
 		}
 
+		article.Signature = signature
+
 		models.IdentifyUser(author, dbInstance)
 
 		// Write current block hash
@@ -90,6 +92,22 @@ func (ctrl ArticleController) Publish(c *gin.Context) {
 			return
 		}
 
+		// Check the deposit
+
+		deposit, err := models.GetDepositPolicy(models.TokenLockResourceArticle)
+
+		if err != nil {
+			dbInstance.Rollback()
+			Error(err.Error(), c)
+			return
+		}
+
+		if err := deposit.Check(author, dbInstance); err != nil {
+			dbInstance.Rollback()
+			Error(err.Error(), c)
+			return
+		}
+
 		// Generate article content
 		articleContent := models.NewArticleContent(article.DNA, article.Content)
 		dbInstance.Save(&articleContent)
@@ -104,6 +122,11 @@ func (ctrl ArticleController) Publish(c *gin.Context) {
 		article.CreatedAt = uint(time.Now().Unix())
 		article.TxStatus = models.TxStatusPending
 
+		// The deposit is recorded with the article and locked once the
+		// article is sent
+
+		lock := recordDeposit(deposit, author.Address, article.DNA, dbInstance)
+
 		// Save metadata on Blockchain
 
 		contentContract, err2 := contracts.GetContentContract()
@@ -126,6 +149,9 @@ func (ctrl ArticleController) Publish(c *gin.Context) {
 		dbInstance.Set("gorm:save_associations", false).Save(&article)
 
 		dbInstance.Commit()
+
+		sendDeposit(lock)
+
 		Success(article, c)
 
 	} else {
@@ -140,7 +166,7 @@ func (ctrl *ArticleController) Get(c *gin.Context) {
 
 	db.GetDb().Preload("Author").Where(article).First(&article)
 
-	if article.ID == 0 {
+	if article.ID == 0 || article.Status == models.ArticleStatusDeleted {
 		ErrorNotFound("article does not exist", c)
 		return
 	}
@@ -191,6 +217,7 @@ func (ctrl *ArticleController) List(c *gin.Context) {
 	in = in.Joins("join group_members on group_members.group_dna=group_articles.group_dna")
 	in = in.Where("group_members.member_address = ?", address)
 	in = in.Where("articles.created_at <= ?", start)
+	in = in.Where("articles.status <> ?", models.ArticleStatusDeleted)
 	in = in.Order("group_articles.created_at desc")
 	in = in.Offset(offsetNum)
 	in = in.Limit(20)
@@ -209,9 +236,41 @@ func (ctrl *ArticleController) Discover(c *gin.Context) {
 	in = in.Joins("join group_articles on group_articles.article_dna=articles.dna")
 	in = in.Select("articles.*, group_articles.group_dna")
 	in = in.Where("articles.tx_status = ?", models.TxStatusConfirmed)
+	in = in.Where("articles.status <> ?", models.ArticleStatusDeleted)
 	in = in.Order("RAND()")
 	in = in.Limit(20)
 	in.Find(&articles)
 
 	Success(articles, c)
-}
\ No newline at end of file
+}
+
+// Delete removes an article, for example once moderators found it to be
+// spam, and releases its deposit. The article stays on Blockchain, the
+// node no longer lists it. Deleting it again retries the release.
+func (ctrl *ArticleController) Delete(c *gin.Context) {
+
+	dbi := db.GetDb()
+
+	article := &models.Article{ DNA: c.Param("dna") }
+
+	dbi.Where(article).First(article)
+
+	if article.ID == 0 {
+		ErrorNotFound("article does not exist", c)
+		return
+	}
+
+	if !checkDepositRelease(models.TokenLockResourceArticle, article.DNA, dbi) {
+		ErrorPendingTx(c)
+		return
+	}
+
+	dbi.Model(article).Update("status", models.ArticleStatusDeleted)
+
+	if err := releaseDeposit(models.TokenLockResourceArticle, article.DNA, dbi); err != nil {
+		Error(err.Error(), c)
+		return
+	}
+
+	Success(article, c)
+}
@@ -32,13 +32,14 @@ type ArticleInteractController struct {}
 
 func (articleInteractCtrl *ArticleInteractController) Like (c *gin.Context) {
 
-	dbi := db.GetDb()
+	dbi := db.GetDb().Begin()
 
 	articleLike := &models.ArticleLike{}
 
 	err := c.Bind(articleLike)
 
 	if err != nil {
+		dbi.Rollback()
 		Error(err.Error(), c)
 		return
 	}
@@ -46,6 +47,7 @@ func (articleInteractCtrl *ArticleInteractController) Like (c *gin.Context) {
 	group, article, err := articleInteractCtrl.retrieveArticleAndGroup(articleLike.GroupDNA, c, dbi)
 
 	if err != nil {
+		dbi.Rollback()
 		Error(err.Error(), c)
 		return
 	}
@@ -54,23 +56,28 @@ func (articleInteractCtrl *ArticleInteractController) Like (c *gin.Context) {
 
 	// Check signature
 
-	check, err := crypto.ExtractUserFromSignature(articleLike.GetSignatureBaseString(), articleLike.Signature)
+	check, signature, err := contracts.VerifySubmission("metadata", articleLike.GroupMemberAddress, articleLike.GetSignatureBaseString(), crypto.LikeMessage(articleLike), articleLike.Signature, dbi)
 
 	if err != nil {
+		dbi.Rollback()
 		Error(err.Error(), c)
 		return
 	}
 
 	if check.Address != articleLike.GroupMemberAddress {
+		dbi.Rollback()
 		ErrorSignature(c)
 		return
 	}
 
+	articleLike.Signature = signature
+
 	checkLike := &models.ArticleLike{ ArticleDNA: articleLike.ArticleDNA, GroupDNA: articleLike.GroupDNA, GroupMemberAddress: articleLike.GroupMemberAddress }
 
 	dbi.Where(checkLike).First(checkLike)
 
 	if checkLike.ID != 0 {
+		dbi.Rollback()
 		Error("like already clicked", c)
 		return
 	}
@@ -81,6 +88,7 @@ func (articleInteractCtrl *ArticleInteractController) Like (c *gin.Context) {
 	dbi.Where(groupMember).First(&groupMember)
 
 	if groupMember.ID == 0 || groupMember.TxStatus == models.TxStatusPending {
+		dbi.Rollback()
 		Error("user not in group", c)
 		return
 	}
@@ -91,11 +99,13 @@ func (articleInteractCtrl *ArticleInteractController) Like (c *gin.Context) {
 	contentContract, err := contracts.GetContentContract()
 
 	if err != nil {
+		dbi.Rollback()
 		Error(err.Error(), c)
 		return
 	}
 
 	if err := contentContract.Like(articleLike); err != nil {
+		dbi.Rollback()
 		Error(err.Error(), c)
 		return
 	}
@@ -103,18 +113,21 @@ func (articleInteractCtrl *ArticleInteractController) Like (c *gin.Context) {
 	// Write to db
 	dbi.Save(&articleLike)
 
+	dbi.Commit()
+
 	Success(articleLike, c)
 }
 
 func (articleInteractCtrl *ArticleInteractController) Comment (c *gin.Context) {
 
-	dbi := db.GetDb()
+	dbi := db.GetDb().Begin()
 
 	articleComment := &models.ArticleComment{}
 
 	err := c.Bind(articleComment)
 
 	if err != nil {
+		dbi.Rollback()
 		Error(err.Error(), c)
 		return
 	}
@@ -122,6 +135,7 @@ func (articleInteractCtrl *ArticleInteractController) Comment (c *gin.Context) {
 	group, article, err := articleInteractCtrl.retrieveArticleAndGroup(articleComment.GroupDNA, c, dbi)
 
 	if err != nil {
+		dbi.Rollback()
 		Error(err.Error(), c)
 		return
 	}
@@ -131,27 +145,33 @@ func (articleInteractCtrl *ArticleInteractController) Comment (c *gin.Context) {
 	if contentHash, err := articleComment.GenerateContentHash(); err == nil {
 		articleComment.ContentHash = contentHash
 	} else {
+		dbi.Rollback()
 		Error(err.Error(), c)
 		return
 	}
 
 	// Check signature
 
-	check, err := crypto.ExtractUserFromSignature(articleComment.GetSignatureBaseString(), articleComment.Signature)
+	check, signature, err := contracts.VerifySubmission("metadata", articleComment.GroupMemberAddress, articleComment.GetSignatureBaseString(), crypto.CommentMessage(articleComment), articleComment.Signature, dbi)
 
 	if err != nil {
+		dbi.Rollback()
 		Error(err.Error(), c)
 		return
 	}
 
 	if check.Address != articleComment.GroupMemberAddress {
+		dbi.Rollback()
 		ErrorSignature(c)
 		return
 	}
 
+	articleComment.Signature = signature
+
 	if contentHash, err := articleComment.GenerateContentHash(); err == nil {
 		articleComment.ContentHash = contentHash
 	} else {
+		dbi.Rollback()
 		Error(err.Error(), c)
 		return
 	}
@@ -162,6 +182,7 @@ func (articleInteractCtrl *ArticleInteractController) Comment (c *gin.Context) {
 	dbi.Where(groupMember).First(&groupMember)
 
 	if groupMember.ID == 0 || groupMember.TxStatus == models.TxStatusPending {
+		dbi.Rollback()
 		Error("user not in group", c)
 		return
 	}
@@ -177,6 +198,7 @@ func (articleInteractCtrl *ArticleInteractController) Comment (c *gin.Context) {
 	dbi.Where(checkComment).First(checkComment)
 
 	if checkComment.ID != 0 {
+		dbi.Rollback()
 		Error("same comment already published", c)
 		return
 	}
@@ -187,29 +209,34 @@ func (articleInteractCtrl *ArticleInteractController) Comment (c *gin.Context) {
 	contentContract, err := contracts.GetContentContract()
 
 	if err != nil {
+		dbi.Rollback()
 		Error(err.Error(), c)
 		return
 	}
 
 	if err:= contentContract.Comment(articleComment); err != nil {
+		dbi.Rollback()
 		Error(err.Error(), c)
 		return
 	}
 
 	dbi.Set("gorm:save_associations", false).Save(&articleComment)
 
+	dbi.Commit()
+
 	Success(articleComment, c)
 }
 
 func (articleInteractCtrl *ArticleInteractController) Share (c *gin.Context) {
 
-	dbi := db.GetDb()
+	dbi := db.GetDb().Begin()
 
 	articleShareBatch := &models.ArticleShareBatch{}
 
 	err := c.Bind(articleShareBatch)
 
 	if err != nil {
+		dbi.Rollback()
 		Error(err.Error(), c)
 		return
 	}
@@ -217,6 +244,7 @@ func (articleInteractCtrl *ArticleInteractController) Share (c *gin.Context) {
 	article := articleInteractCtrl.retrieveArticle(c, dbi)
 
 	if article == nil {
+		dbi.Rollback()
 		Error("article does not exist", c)
 		return
 	}
@@ -227,18 +255,22 @@ func (articleInteractCtrl *ArticleInteractController) Share (c *gin.Context) {
 
 	sigBase := articleShareBatch.GetSignatureBaseString()
 
-	check, err := crypto.ExtractUserFromSignature(sigBase, articleShareBatch.Signature)
+	check, signature, err := contracts.VerifySubmission("metadata", articleShareBatch.GroupMemberAddress, sigBase, crypto.ShareMessage(articleShareBatch), articleShareBatch.Signature, dbi)
 
 	if err != nil {
+		dbi.Rollback()
 		Error(err.Error(), c)
 		return
 	}
 
 	if check.Address != articleShareBatch.GroupMemberAddress {
+		dbi.Rollback()
 		ErrorSignature(c)
 		return
 	}
 
+	articleShareBatch.Signature = signature
+
 	// Check group membership
 
 	for _, groupDNA := range articleShareBatch.GroupDNAs {
@@ -248,6 +280,7 @@ func (articleInteractCtrl *ArticleInteractController) Share (c *gin.Context) {
 		dbi.Where(cc).First(&cc)
 
 		if cc.ID == 0 {
+			dbi.Rollback()
 			Error("user not in group", c)
 			return
 		}
@@ -257,6 +290,7 @@ func (articleInteractCtrl *ArticleInteractController) Share (c *gin.Context) {
 		dbi.Where(ac).First(ac)
 
 		if ac.ID != 0 {
+			dbi.Rollback()
 			Error("article already shared in this group", c)
 			return
 		}
@@ -265,11 +299,13 @@ func (articleInteractCtrl *ArticleInteractController) Share (c *gin.Context) {
 	contentContract, err := contracts.GetContentContract()
 
 	if err != nil {
+		dbi.Rollback()
 		Error(err.Error(), c)
 		return
 	}
 
 	if err := contentContract.Share(articleShareBatch); err != nil {
+		dbi.Rollback()
 		Error(err.Error(), c)
 		return
 	}
@@ -284,6 +320,8 @@ func (articleInteractCtrl *ArticleInteractController) Share (c *gin.Context) {
 		dbi.Save(groupArticle)
 	}
 
+	dbi.Commit()
+
 	Success(articleShareBatch, c)
 }
 
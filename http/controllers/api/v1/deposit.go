@@ -0,0 +1,90 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1
+
+import (
+	"log"
+	"github.com/jinzhu/gorm"
+	"github.com/primasio/primas-node/contracts"
+	"github.com/primasio/primas-node/db"
+	"github.com/primasio/primas-node/models"
+)
+
+// recordDeposit records the deposit of a new resource when the policy
+// requires one, in db, the transaction of the request in which the
+// deposit was checked. Nothing is sent until the resource is.
+func recordDeposit(policy *models.DepositPolicy, userAddress string, dna string, db *gorm.DB) *models.TokenLock {
+
+	if !policy.Enabled() {
+		return nil
+	}
+
+	lock := policy.NewLock(userAddress, dna)
+
+	db.Save(lock)
+
+	return lock
+}
+
+// sendDeposit sends the lock of a deposit once the transaction creating
+// its resource was sent and the request committed. A deposit left unsent
+// is sent by the unlock_tokens job.
+func sendDeposit(lock *models.TokenLock) {
+
+	if lock == nil {
+		return
+	}
+
+	tokenContract, err := contracts.GetTokenContract()
+
+	if err == nil {
+		err = tokenContract.LockDeposit(lock, db.GetDb())
+	}
+
+	if err != nil {
+		log.Println("deposit lock failed for " + lock.ResourceDNA + ": " + err.Error())
+	}
+}
+
+// checkDepositRelease makes sure the deposit of a resource can be released,
+// its lock transaction must be confirmed first.
+func checkDepositRelease(resourceType uint, dna string, db *gorm.DB) bool {
+
+	lock := models.GetDepositLock(resourceType, dna, db)
+
+	return lock.ID == 0 || lock.TxStatus != models.TxStatusPending
+}
+
+// releaseDeposit releases the deposit of a deleted resource. Status locks
+// are unlocked right away. Time locks can not be unlocked before they
+// expire, they stay queued for the unlock_tokens job.
+func releaseDeposit(resourceType uint, dna string, db *gorm.DB) error {
+
+	lock := models.GetDepositLock(resourceType, dna, db)
+
+	if lock.ID == 0 || lock.IsTimeLock() {
+		return nil
+	}
+
+	tokenContract, err := contracts.GetTokenContract()
+
+	if err != nil {
+		return err
+	}
+
+	return tokenContract.SubmitUnlock(lock, db)
+}
@@ -39,7 +39,7 @@ func (groupCtrl *GroupController) Create (c *gin.Context) {
 
 		// Validate creator signature
 
-		creator, err := crypto.ExtractUserFromSignature(group.GetSignatureBaseString(), group.Signature)
+		creator, signature, err := contracts.VerifySubmission("group", group.UserAddress, group.GetSignatureBaseString(), crypto.GroupMessage(group), group.Signature, tx)
 
 		if err != nil {
 			tx.Rollback()
@@ -53,6 +53,8 @@ func (groupCtrl *GroupController) Create (c *gin.Context) {
 			return
 		}
 
+		group.Signature = signature
+
 		models.IdentifyUser(creator, tx)
 
 		// Generate group DNA
@@ -72,6 +74,26 @@ func (groupCtrl *GroupController) Create (c *gin.Context) {
 			return
 		}
 
+		// Lock the deposit
+
+		deposit, err := models.GetDepositPolicy(models.TokenLockResourceGroup)
+
+		if err != nil {
+			tx.Rollback()
+			Error(err.Error(), c)
+			return
+		}
+
+		if err := deposit.Check(creator, tx); err != nil {
+			tx.Rollback()
+			Error(err.Error(), c)
+			return
+		}
+
+		// Locked once the group is sent
+
+		lock := recordDeposit(deposit, creator.Address, group.DNA, tx)
+
 		// Add creator to group member
 
 		groupMember := &models.GroupMember{}
@@ -85,11 +107,13 @@ func (groupCtrl *GroupController) Create (c *gin.Context) {
 		groupContract, err := contracts.GetGroupContract()
 
 		if err != nil {
+			tx.Rollback()
 			Error(err.Error(), c)
 			return
 		}
 
 		if err := groupContract.Create(group); err != nil {
+			tx.Rollback()
 			Error(err.Error(), c)
 			return
 		}
@@ -98,6 +122,9 @@ func (groupCtrl *GroupController) Create (c *gin.Context) {
 		tx.Save(&group)
 
 		tx.Commit()
+
+		sendDeposit(lock)
+
 		Success(group, c)
 
 	} else {
@@ -107,11 +134,12 @@ func (groupCtrl *GroupController) Create (c *gin.Context) {
 
 func (groupCtrl *GroupController) AddMember(c *gin.Context) {
 
-	dbi := db.GetDb()
+	dbi := db.GetDb().Begin()
 
 	group := groupCtrl.retrieveGroup(c, dbi)
 
 	if group == nil {
+		dbi.Rollback()
 		ErrorNotFound("group does not exist", c)
 		return
 	}
@@ -121,6 +149,7 @@ func (groupCtrl *GroupController) AddMember(c *gin.Context) {
 	err := c.Bind(groupMember)
 
 	if err != nil {
+		dbi.Rollback()
 		Error(err.Error(), c)
 		return
 	}
@@ -128,21 +157,28 @@ func (groupCtrl *GroupController) AddMember(c *gin.Context) {
 	groupMember.GroupDNA = group.DNA
 
 	// Validate Signature
-	check, err := crypto.ExtractUserFromSignature(groupMember.GetSignatureBaseString(), groupMember.Signature)
+	check, signature, err := contracts.VerifySubmission("group", groupMember.MemberAddress, groupMember.GetSignatureBaseString(), crypto.JoinGroupMessage(groupMember), groupMember.Signature, dbi)
 
 	if err != nil {
+		dbi.Rollback()
 		Error(err.Error(), c)
 		return
 	}
 
 	if check.Address != groupMember.MemberAddress {
+		dbi.Rollback()
 		ErrorSignature(c)
 		return
 	}
 
-	dbi.Where(groupMember).First(&groupMember)
+	groupMember.Signature = signature
 
-	if groupMember.ID != 0 {
+	existing := &models.GroupMember{ GroupDNA: groupMember.GroupDNA, MemberAddress: groupMember.MemberAddress }
+
+	dbi.Where(existing).First(existing)
+
+	if existing.ID != 0 {
+		dbi.Rollback()
 		Error("member already in group", c)
 		return
 	}
@@ -154,27 +190,32 @@ func (groupCtrl *GroupController) AddMember(c *gin.Context) {
 	groupContract, err := contracts.GetGroupContract()
 
 	if err != nil {
+		dbi.Rollback()
 		Error(err.Error(), c)
 		return
 	}
 
 	if err := groupContract.AddMember(groupMember); err != nil {
+		dbi.Rollback()
 		Error(err.Error(), c)
 		return
 	}
 
 	dbi.Save(&groupMember)
 
+	dbi.Commit()
+
 	Success(groupMember, c)
 }
 
 func (groupCtrl *GroupController) RemoveMember(c *gin.Context) {
 
-	dbi := db.GetDb()
+	dbi := db.GetDb().Begin()
 
 	group := groupCtrl.retrieveGroup(c, dbi)
 
 	if group == nil {
+		dbi.Rollback()
 		ErrorNotFound("group does not exist", c)
 		return
 	}
@@ -184,34 +225,44 @@ func (groupCtrl *GroupController) RemoveMember(c *gin.Context) {
 	err := c.Bind(groupMember)
 
 	if err != nil {
+		dbi.Rollback()
 		Error(err.Error(), c)
 		return
 	}
 
 	groupMember.GroupDNA = group.DNA
 
-	check, err := crypto.ExtractUserFromSignature(groupMember.GetSignatureBaseString(), groupMember.Signature)
+	check, signature, err := contracts.VerifySubmission("group", groupMember.MemberAddress, groupMember.GetSignatureBaseString(), crypto.LeaveGroupMessage(groupMember), groupMember.Signature, dbi)
 
 	if err != nil {
+		dbi.Rollback()
 		Error(err.Error(), c)
 		return
 	}
 
 	if check.Address != groupMember.MemberAddress {
+		dbi.Rollback()
 		ErrorSignature(c)
 		return
 	}
 
-	dbi.Where(groupMember).First(&groupMember)
+	// The signature of the removal is sent to Blockchain, not the one the
+	// member joined with
+
+	dbi.Where(&models.GroupMember{ GroupDNA: groupMember.GroupDNA, MemberAddress: groupMember.MemberAddress }).First(&groupMember)
 
 	if groupMember.ID == 0 {
+		dbi.Rollback()
 		ErrorNotFound("member does not exist", c)
 		return
 	}
 
+	groupMember.Signature = signature
+
 	if groupMember.TxStatus != models.TxStatusConfirmed {
 
 		// Last modification is not confirmed yet
+		dbi.Rollback()
 		ErrorPendingTx(c)
 		return
 	}
@@ -219,11 +270,13 @@ func (groupCtrl *GroupController) RemoveMember(c *gin.Context) {
 	groupContract, err := contracts.GetGroupContract()
 
 	if err != nil {
+		dbi.Rollback()
 		Error(err.Error(), c)
 		return
 	}
 
 	if err := groupContract.RemoveMember(groupMember); err != nil {
+		dbi.Rollback()
 		Error(err.Error(), c)
 		return
 	}
@@ -233,16 +286,19 @@ func (groupCtrl *GroupController) RemoveMember(c *gin.Context) {
 	groupMember.TxStatus = models.TxStatusPending
 	dbi.Save(&groupMember)
 
+	dbi.Commit()
+
 	Success(groupMember, c)
 }
 
 func (groupCtrl *GroupController) RemoveMemberByOwner(c *gin.Context) {
 
-	dbi := db.GetDb()
+	dbi := db.GetDb().Begin()
 
 	group := groupCtrl.retrieveGroup(c, dbi)
 
 	if group == nil {
+		dbi.Rollback()
 		ErrorNotFound("group does not exist", c)
 		return
 	}
@@ -252,34 +308,44 @@ func (groupCtrl *GroupController) RemoveMemberByOwner(c *gin.Context) {
 	err := c.Bind(groupMember)
 
 	if err != nil {
+		dbi.Rollback()
 		Error(err.Error(), c)
 		return
 	}
 
 	groupMember.GroupDNA = group.DNA
 
-	check, err := crypto.ExtractUserFromSignature(groupMember.GetOwnerSignatureBaseString(), groupMember.Signature)
+	check, signature, err := contracts.VerifySubmission("group", group.UserAddress, groupMember.GetOwnerSignatureBaseString(), crypto.RemoveGroupMemberMessage(groupMember), groupMember.Signature, dbi)
 
 	if err != nil {
+		dbi.Rollback()
 		Error(err.Error(), c)
 		return
 	}
 
 	if check.Address != group.UserAddress {
+		dbi.Rollback()
 		ErrorSignature(c)
 		return
 	}
 
-	dbi.Where(groupMember).First(&groupMember)
+	// The signature of the removal is sent to Blockchain, not the one the
+	// member joined with
+
+	dbi.Where(&models.GroupMember{ GroupDNA: groupMember.GroupDNA, MemberAddress: groupMember.MemberAddress }).First(&groupMember)
 
 	if groupMember.ID == 0 {
+		dbi.Rollback()
 		ErrorNotFound("member does not exist", c)
 		return
 	}
 
+	groupMember.Signature = signature
+
 	if groupMember.TxStatus != models.TxStatusConfirmed {
 
 		// Last modification is not confirmed yet
+		dbi.Rollback()
 		ErrorPendingTx(c)
 		return
 	}
@@ -287,11 +353,13 @@ func (groupCtrl *GroupController) RemoveMemberByOwner(c *gin.Context) {
 	groupContract, err := contracts.GetGroupContract()
 
 	if err != nil {
+		dbi.Rollback()
 		Error(err.Error(), c)
 		return
 	}
 
 	if err := groupContract.RemoveMemberByOwner(groupMember, group.UserAddress); err != nil {
+		dbi.Rollback()
 		Error(err.Error(), c)
 		return
 	}
@@ -300,6 +368,8 @@ func (groupCtrl *GroupController) RemoveMemberByOwner(c *gin.Context) {
 	groupMember.TxStatus = models.TxStatusPending
 	dbi.Save(&groupMember)
 
+	dbi.Commit()
+
 	Success(groupMember, c)
 }
 
@@ -333,6 +403,7 @@ func (groupCtrl *GroupController) Get (c *gin.Context) {
 	in := dbi.Table("articles")
 	in = in.Joins("join group_articles on group_articles.article_dna=articles.dna")
 	in = in.Where(&models.GroupArticle{GroupDNA:group.DNA})
+	in = in.Where("articles.status <> ?", models.ArticleStatusDeleted)
 	in = in.Order("articles.created_at desc")
 	in = in.Limit(20)
 
@@ -376,6 +447,7 @@ func (groupCtrl *GroupController) GetArticles(c *gin.Context) {
 	in = in.Joins("join group_articles on group_articles.article_dna=articles.dna")
 	in = in.Where(&models.GroupArticle{GroupDNA:group.DNA})
 	in = in.Where("articles.created_at <= ?", start)
+	in = in.Where("articles.status <> ?", models.ArticleStatusDeleted)
 	in = in.Order("created_at DESC").Offset(offsetNum).Limit(20).Find(&articles)
 
 	Success(articles, c)
@@ -442,6 +514,7 @@ func (groupCtrl *GroupController) List (c *gin.Context) {
 
 	in = in.Joins("join group_members on group_members.group_dna=groups.dna")
 	in = in.Where(&models.GroupMember{MemberAddress:address})
+	in = in.Where("groups.status <> ?", models.GroupStatusDeleted)
 	in = in.Offset(offsetNum).Limit(20).Order("created_at desc").Find(&groups)
 	Success(groups, c)
 }
@@ -454,6 +527,7 @@ func (groupCtrl *GroupController) Discover(c *gin.Context) {
 	in := dbi.Table("groups")
 	in = in.Order("RAND()")
 	in = in.Where("tx_status = ?", models.TxStatusConfirmed)
+	in = in.Where("status <> ?", models.GroupStatusDeleted)
 	in = in.Limit(20)
 
 	in.Find(&groups)
@@ -472,9 +546,40 @@ func (groupCtrl *GroupController) retrieveGroup(c *gin.Context, db *gorm.DB) *mo
 
 	db.Where(group).First(&group)
 
-	if group.ID == 0 || group.TxStatus == models.TxStatusPending {
+	if group.ID == 0 || group.TxStatus == models.TxStatusPending || group.Status == models.GroupStatusDeleted {
 		return nil
 	}
 
 	return group
-}
\ No newline at end of file
+}
+
+// Delete removes a group, for example once moderators found it to be spam,
+// and releases its deposit. The group stays on Blockchain, the node no
+// longer lists it. Deleting it again retries the release.
+func (groupCtrl *GroupController) Delete(c *gin.Context) {
+
+	dbi := db.GetDb()
+
+	group := &models.Group{ DNA: c.Param("dna") }
+
+	dbi.Where(group).First(group)
+
+	if group.ID == 0 {
+		ErrorNotFound("group does not exist", c)
+		return
+	}
+
+	if !checkDepositRelease(models.TokenLockResourceGroup, group.DNA, dbi) {
+		ErrorPendingTx(c)
+		return
+	}
+
+	dbi.Model(group).Update("status", models.GroupStatusDeleted)
+
+	if err := releaseDeposit(models.TokenLockResourceGroup, group.DNA, dbi); err != nil {
+		Error(err.Error(), c)
+		return
+	}
+
+	Success(group, c)
+}
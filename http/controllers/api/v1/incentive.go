@@ -21,6 +21,11 @@ import (
 	"github.com/primasio/primas-node/models"
 	"github.com/primasio/primas-node/db"
 	"strconv"
+	"math/big"
+	"net/http"
+	"github.com/primasio/primas-node/incentives"
+	"strings"
+	"time"
 )
 
 type IncentiveController struct{}
@@ -58,10 +63,22 @@ func (incentiveCtrl *IncentiveController) GetUserTotalIncentive (c *gin.Context)
 
 	if address == "" {
 		Error("invalid parameters", c)
+		return
 	}
 
-	from := c.Query("from")
-	to := c.Query("to")
+	from, err := strconv.ParseUint(c.Query("from"), 10, 64)
+
+	if err != nil {
+		Error("invalid parameters", c)
+		return
+	}
+
+	to, err := strconv.ParseUint(c.Query("to"), 10, 64)
+
+	if err != nil || to < from {
+		Error("invalid parameters", c)
+		return
+	}
 
 	incentive := &models.Incentive{ UserAddress: address, Status:models.IncentivesPaid }
 
@@ -76,3 +93,220 @@ func (incentiveCtrl *IncentiveController) GetUserTotalIncentive (c *gin.Context)
 
 	Success(totalScore.Score, c)
 }
+
+// Simulate previews the distribution of the given amount against the
+// current pending incentives without changing them.
+func (incentiveCtrl *IncentiveController) Simulate (c *gin.Context) {
+
+	amount, ok := new(big.Int).SetString(c.Query("amount"), 10)
+
+	if !ok || amount.Sign() < 0 {
+		Error("invalid parameters", c)
+		return
+	}
+
+	policy, err := incentives.LoadPolicy()
+
+	if err != nil {
+		Error(err.Error(), c)
+		return
+	}
+
+	if ranking := c.Query("ranking"); ranking != "" {
+
+		policy.Ranking = ranking
+
+		if err := policy.Validate(); err != nil {
+			Error(err.Error(), c)
+			return
+		}
+	}
+
+	simulation, err := incentives.Simulate(amount, policy, db.GetDb())
+
+	if err != nil {
+		Error(err.Error(), c)
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		c.Header("Content-Type", "text/csv")
+		c.Status(http.StatusOK)
+		simulation.WriteCSV(c.Writer)
+		return
+	}
+
+	Success(simulation, c)
+}
+
+func (incentiveCtrl *IncentiveController) GetRun (c *gin.Context) {
+
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		Error("invalid parameters", c)
+		return
+	}
+
+	dbi := db.GetDb()
+
+	run := &models.IncentiveRun{}
+
+	dbi.Where("id = ?", id).First(run)
+
+	if run.ID == 0 {
+		ErrorNotFound("incentive run does not exist", c)
+		return
+	}
+
+	Success(run.GetReport(dbi), c)
+}
+
+// RevertRun puts the records of an unpaid run back to pending.
+func (incentiveCtrl *IncentiveController) RevertRun (c *gin.Context) {
+
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		Error("invalid parameters", c)
+		return
+	}
+
+	tx := db.GetDb().Begin()
+
+	run := &models.IncentiveRun{}
+
+	tx.Set("gorm:query_option", "FOR UPDATE").Where("id = ?", id).First(run)
+
+	if run.ID == 0 {
+		tx.Rollback()
+		ErrorNotFound("incentive run does not exist", c)
+		return
+	}
+
+	if run.Status == models.IncentiveRunReverted {
+		tx.Rollback()
+		Error("incentive run is already reverted", c)
+		return
+	}
+
+	if err := run.Revert(tx); err != nil {
+		tx.Rollback()
+		Error(err.Error(), c)
+		return
+	}
+
+	tx.Commit()
+
+	Success(run, c)
+}
+
+// ListFlags lists the interactions discounted by the filter, optionally
+// limited to a run.
+func (incentiveCtrl *IncentiveController) ListFlags (c *gin.Context) {
+
+	offsetNum := 0
+	offset := c.Query("offset")
+
+	if offset != "" {
+		if num, err := strconv.Atoi(offset); err == nil {
+			offsetNum = num
+		}
+	}
+
+	var flags []models.IncentiveFlag
+
+	in := db.GetDb().Model(&models.IncentiveFlag{})
+
+	if runID := c.Query("run_id"); runID != "" {
+		in = in.Where("run_id = ?", runID)
+	}
+
+	if address := c.Query("address"); address != "" {
+		in = in.Where("user_address = ?", address)
+	}
+
+	in = in.Order("id desc").Offset(offsetNum).Limit(20)
+	in.Find(&flags)
+
+	Success(flags, c)
+}
+
+// ListBatches lists the grant batches, optionally limited to a status. The
+// batches abandoned after incentives.max_attempts are listed with status 4.
+func (incentiveCtrl *IncentiveController) ListBatches (c *gin.Context) {
+
+	offsetNum := 0
+	offset := c.Query("offset")
+
+	if offset != "" {
+		if num, err := strconv.Atoi(offset); err == nil {
+			offsetNum = num
+		}
+	}
+
+	var batches []models.IncentiveBatch
+
+	in := db.GetDb().Model(&models.IncentiveBatch{})
+
+	if status := c.Query("status"); status != "" {
+		in = in.Where("status = ?", status)
+	}
+
+	in = in.Order("id desc").Offset(offsetNum).Limit(20)
+	in.Find(&batches)
+
+	Success(batches, c)
+}
+
+// maxStatementDays limits the range of a statement
+const maxStatementDays = 366
+
+// GetUserStatement returns the incentives paid to a user between the from
+// and to days (YYYY-MM-DD, UTC, both included), grouped by day and type.
+// The statement is exported as CSV when requested with Accept: text/csv.
+func (incentiveCtrl *IncentiveController) GetUserStatement (c *gin.Context) {
+
+	address := c.Param("address")
+
+	if address == "" {
+		Error("invalid parameters", c)
+		return
+	}
+
+	from, err := time.Parse(models.StatementDateFormat, c.Query("from"))
+
+	if err != nil {
+		Error("invalid from date", c)
+		return
+	}
+
+	to, err := time.Parse(models.StatementDateFormat, c.Query("to"))
+
+	if err != nil {
+		Error("invalid to date", c)
+		return
+	}
+
+	if to.Before(from) {
+		Error("from date is after to date", c)
+		return
+	}
+
+	if to.Sub(from) >= maxStatementDays * 24 * time.Hour {
+		Error("statement range is limited to " + strconv.Itoa(maxStatementDays) + " days", c)
+		return
+	}
+
+	statement := models.GetIncentiveStatement(address, from, to, db.GetDb())
+
+	if strings.Contains(c.Request.Header.Get("Accept"), "text/csv") {
+		c.Header("Content-Type", "text/csv")
+		c.Header("Content-Disposition", "attachment; filename=incentives-" + statement.From + "-" + statement.To + ".csv")
+		c.Status(http.StatusOK)
+		statement.WriteCSV(c.Writer)
+		return
+	}
+
+	Success(statement, c)
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/primasio/primas-node/models"
+	"github.com/primasio/primas-node/db"
+	"strconv"
+)
+
+type JobController struct{}
+
+func (jobCtrl *JobController) List (c *gin.Context) {
+
+	var jobs []models.SchedulerJob
+
+	db.GetDb().Order("name asc").Find(&jobs)
+
+	Success(jobs, c)
+}
+
+// Trigger asks the instance running the scheduler to run the job on its
+// next tick, even if the job is paused.
+func (jobCtrl *JobController) Trigger (c *gin.Context) {
+	jobCtrl.update(c, map[string]interface{}{"trigger_requested": true})
+}
+
+func (jobCtrl *JobController) Pause (c *gin.Context) {
+	jobCtrl.update(c, map[string]interface{}{"paused": true})
+}
+
+func (jobCtrl *JobController) Resume (c *gin.Context) {
+	jobCtrl.update(c, map[string]interface{}{"paused": false})
+}
+
+func (jobCtrl *JobController) GetRuns (c *gin.Context) {
+
+	offsetNum := 0
+	offset := c.Query("offset")
+
+	if offset != "" {
+		if num, err := strconv.Atoi(offset); err == nil {
+			offsetNum = num
+		}
+	}
+
+	var runs []models.JobRun
+
+	in := db.GetDb().Where(&models.JobRun{ JobName: c.Param("name") })
+	in = in.Order("id desc").Offset(offsetNum).Limit(20)
+	in.Find(&runs)
+
+	Success(runs, c)
+}
+
+func (jobCtrl *JobController) update (c *gin.Context, values map[string]interface{}) {
+
+	dbi := db.GetDb()
+
+	job := &models.SchedulerJob{}
+
+	dbi.Where(&models.SchedulerJob{ Name: c.Param("name") }).First(job)
+
+	if job.ID == 0 {
+		ErrorNotFound("job does not exist", c)
+		return
+	}
+
+	dbi.Model(job).Updates(values)
+
+	Success(job, c)
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/primasio/primas-node/models"
+	"github.com/primasio/primas-node/db"
+	"strconv"
+)
+
+type NodeController struct{}
+
+func (nodeCtrl *NodeController) List (c *gin.Context) {
+
+	offsetNum := 0
+	offset := c.Query("offset")
+
+	if offset != "" {
+		if num, err := strconv.Atoi(offset); err == nil {
+			offsetNum = num
+		}
+	}
+
+	var nodes []models.Node
+
+	in := db.GetDb().Order("last_active_block desc")
+	in = in.Offset(offsetNum).Limit(20)
+	in.Find(&nodes)
+
+	Success(nodes, c)
+}
+
+func (nodeCtrl *NodeController) Get (c *gin.Context) {
+
+	addr := c.Param("address")
+
+	if addr == "" {
+		Error("invalid parameters", c)
+		return
+	}
+
+	node := &models.Node{ Address: addr }
+
+	db.GetDb().Where(node).First(node)
+
+	if node.ID == 0 {
+		ErrorNotFound("node does not exist", c)
+		return
+	}
+
+	Success(node, c)
+}
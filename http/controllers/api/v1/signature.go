@@ -0,0 +1,64 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/primasio/primas-node/contracts"
+	"github.com/primasio/primas-node/db"
+	"github.com/primasio/primas-node/models"
+)
+
+type SignatureController struct{}
+
+// GetDomain returns the EIP-712 domain to sign actions recorded by a
+// contract: "metadata" for articles, likes, comments and shares, "group"
+// for groups and members.
+func (signatureCtrl *SignatureController) GetDomain (c *gin.Context) {
+
+	contractName := c.Param("contract")
+
+	if contractName != "metadata" && contractName != "group" {
+		ErrorNotFound("contract does not exist", c)
+		return
+	}
+
+	domain, err := contracts.GetSignatureDomain(contractName)
+
+	if err != nil {
+		Error(err.Error(), c)
+		return
+	}
+
+	Success(domain, c)
+}
+
+// GetNonce returns the last nonce used by the user. The next signature
+// must use a greater one.
+func (signatureCtrl *SignatureController) GetNonce (c *gin.Context) {
+
+	addr := c.Param("address")
+
+	if addr == "" {
+		Error("invalid parameters", c)
+		return
+	}
+
+	nonce := models.GetSignatureNonce(addr, db.GetDb())
+
+	Success(map[string]interface{}{ "UserAddress": addr, "Nonce": nonce, "NextNonce": nonce + 1 }, c)
+}
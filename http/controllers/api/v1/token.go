@@ -0,0 +1,59 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/primasio/primas-node/models"
+	"github.com/primasio/primas-node/db"
+	"strconv"
+)
+
+type TokenController struct{}
+
+// GetAllowances lists the allowances of an owner or of a spender.
+func (tokenCtrl *TokenController) GetAllowances (c *gin.Context) {
+
+	owner := c.Query("owner")
+	spender := c.Query("spender")
+
+	if owner == "" && spender == "" {
+		Error("invalid parameters", c)
+		return
+	}
+
+	Success(models.GetAllowances(owner, spender, queryOffset(c), db.GetDb()), c)
+}
+
+// GetAdminHistory lists the ownership transfers of the token contract.
+func (tokenCtrl *TokenController) GetAdminHistory (c *gin.Context) {
+	Success(models.GetContractAdminHistory("token", queryOffset(c), db.GetDb()), c)
+}
+
+func queryOffset(c *gin.Context) int {
+
+	offsetNum := 0
+	offset := c.Query("offset")
+
+	if offset != "" {
+		if num, err := strconv.Atoi(offset); err == nil {
+			offsetNum = num
+		}
+	}
+
+	return offsetNum
+}
@@ -20,12 +20,12 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/primasio/primas-node/models"
 	"github.com/primasio/primas-node/db"
-	"github.com/ethereum/go-ethereum/crypto/secp256k1"
-	"github.com/ethereum/go-ethereum/crypto"
 	"time"
-	"encoding/hex"
 	"strconv"
 	"github.com/primasio/primas-node/contracts"
+	"log"
+	"github.com/primasio/primas-node/crypto"
+	"encoding/hex"
 )
 
 type UserController struct {}
@@ -42,54 +42,132 @@ func (userCtrl *UserController) Update (c *gin.Context) {
 
 	// Validate author signature
 
-	msg :=  crypto.Keccak256([]byte(user.Name + user.Extra))
-
-	sigBytes, err := hex.DecodeString(user.Signature)
+	signer, err := crypto.ExtractClaimedUserFromSignature(user.GetSignatureBaseString(), user.Signature, user.Address)
 
 	if err != nil {
 		Error(err.Error(), c)
 		return
 	}
 
-	publicKey, err := secp256k1.RecoverPubkey(msg, sigBytes)
-
-	if err != nil {
-		Error(err.Error(), c)
+	if signer.Address != user.Address {
+		Error("invalid signature", c)
 		return
 	}
 
-	pk := crypto.ToECDSAPub(publicKey)
-	addr := crypto.PubkeyToAddress(*pk)
+	check := &models.User{}
+	check.Address = signer.Address
+	models.IdentifyUser(check, db.GetDb())
 
-	if addr.Hex() != user.Address {
-		Error("invalid signature", c)
-		return
+	// Name and icon are updated on Blockchain, each with its own
+	// signature, and applied once confirmed
+
+	var updates []*models.ProfileUpdate
+
+	if user.Name != check.Name {
+		updates = append(updates, models.NewProfileUpdate(check, models.ProfileFieldName, user.Name, user.NameSignature))
 	}
 
-	// TODO: Update user data on Blockchain
+	if user.Icon != check.Icon {
+		updates = append(updates, models.NewProfileUpdate(check, models.ProfileFieldIcon, user.Icon, user.IconSignature))
+	}
 
-	dbi := db.GetDb()
+	if len(updates) == 0 {
 
-	check := &models.User{}
-	check.Address = addr.Hex()
-	dbi.Where(check).First(&check)
+		dbi := db.GetDb().Begin()
 
-	if check.ID != 0 {
-		// Modify existing data
-		check.Name = user.Name
 		check.Signature = user.Signature
 		check.Extra = user.Extra
+
 		dbi.Save(check)
+		dbi.Commit()
 
 		Success(check, c)
-	} else {
-		// Create new data
-		user.Address = addr.Hex()
-		user.CreatedAt = uint(time.Now().Unix())
-		dbi.Save(&user)
+		return
+	}
 
-		Success(user, c)
+	// Both updates are validated and estimated before anything is saved
+	// or sent, no database transaction is held during the node calls
+
+	userContract, err := contracts.GetUserContract()
+
+	if err != nil {
+		Error(err.Error(), c)
+		return
 	}
+
+	for _, update := range updates {
+
+		if update.Signature == "" {
+			Error("missing " + update.Field + " signature", c)
+			return
+		}
+
+		// Sent to the user contract as it verifies them
+
+		sigBytes, err := crypto.ContractSignature(update.Signature)
+
+		if err != nil {
+			Error(update.Field + " signature: " + err.Error(), c)
+			return
+		}
+
+		update.Signature = hex.EncodeToString(sigBytes)
+
+		if err := userContract.CheckProfileUpdate(update); err != nil {
+			Error(err.Error(), c)
+			return
+		}
+	}
+
+	// The extra data and the profile signature are kept with the updates
+	// until they are all confirmed
+
+	dbi := db.GetDb().Begin()
+
+	for _, update := range updates {
+
+		update.Extra = user.Extra
+		update.ProfileSignature = user.Signature
+
+		dbi.Save(update)
+
+		update.RequestID = updates[0].ID
+		dbi.Save(update)
+	}
+
+	check.TxStatus = models.TxStatusPending
+
+	dbi.Model(check).Update("tx_status", check.TxStatus)
+	dbi.Commit()
+
+	// Each update is saved with its transaction hash once sent. The
+	// request can not complete once one fails, the rest are failed too
+
+	for i, update := range updates {
+		if err := userContract.UpdateProfile(update, db.GetDb()); err != nil {
+
+			for _, unsent := range updates[i:] {
+				unsent.Fail(err.Error(), db.GetDb())
+			}
+
+			Error(err.Error(), c)
+			return
+		}
+	}
+
+	Success(check, c)
+}
+
+// GetProfileHistory lists the name and icon updates of the user with
+// their transaction status.
+func (userCtrl *UserController) GetProfileHistory (c *gin.Context) {
+	addr := c.Param("address")
+	if addr == "" {
+		Error("invalid parameters", c)
+		return
+	}
+
+	Success(models.GetProfileHistory(addr, queryOffset(c), db.GetDb()), c)
 }
 
 func (userCtrl *UserController) Get (c *gin.Context) {
@@ -116,6 +194,7 @@ func (userCtrl *UserController) Get (c *gin.Context) {
 
 	in := dbi.Table("articles")
 	in = in.Where(&models.Article{UserAddress: addr})
+	in = in.Where("status <> ?", models.ArticleStatusDeleted)
 	in = in.Order("created_at desc").Limit(20)
 
 	in.Find(&articles)
@@ -128,6 +207,7 @@ func (userCtrl *UserController) Get (c *gin.Context) {
 
 	in2 := dbi.Table("groups")
 	in2 = in2.Where(&models.Group{UserAddress: addr})
+	in2 = in2.Where("status <> ?", models.GroupStatusDeleted)
 	in2 = in2.Order("created_at desc").Limit(20)
 
 	in2.Find(&groups)
@@ -158,7 +238,7 @@ func (userCtrl *UserController) GetGroups (c *gin.Context) {
 
 	var groups [] models.Group
 
-	dbi.Where(&models.Group{ UserAddress: addr}).Order("created_at desc").Offset(offsetNum).Limit(20).Find(&groups)
+	dbi.Where(&models.Group{ UserAddress: addr}).Where("status <> ?", models.GroupStatusDeleted).Order("created_at desc").Offset(offsetNum).Limit(20).Find(&groups)
 
 	Success(groups, c)
 }
@@ -184,7 +264,7 @@ func (userCtrl *UserController) GetArticles (c *gin.Context) {
 
 	var articles [] models.Article
 
-	dbi.Where(&models.Article{ UserAddress: addr}).Order("created_at desc").Offset(offsetNum).Limit(20).Find(&articles)
+	dbi.Where(&models.Article{ UserAddress: addr}).Where("status <> ?", models.ArticleStatusDeleted).Order("created_at desc").Offset(offsetNum).Limit(20).Find(&articles)
 
 	Success(articles, c)
 }
@@ -215,6 +295,7 @@ func (userCtrl *UserController) GetGroupArticles (c *gin.Context) {
 	in := dbi.Table("articles")
 	in = in.Joins("join group_articles on group_articles.article_dna = articles.dna")
 	in = in.Where(&models.GroupArticle{GroupDNA: dna, MemberAddress: addr})
+	in = in.Where("articles.status <> ?", models.ArticleStatusDeleted)
 	in = in.Order("created_at desc").Offset(offsetNum).Limit(20)
 
 	in.Find(&articles)
@@ -268,6 +349,98 @@ func (userCtrl *UserController) GetLockedBalance(c *gin.Context) {
 	Success(balance.String(), c)
 }
 
+// GetLocks lists the active, expired and unlocked token locks of the user.
+func (userCtrl *UserController) GetLocks(c *gin.Context) {
+	addr := c.Param("address")
+	if addr == "" {
+		Error("invalid parameters", c)
+		return
+	}
+
+	user := &models.User{ Address:addr }
+
+	dbi := db.GetDb()
+
+	dbi.Where(user).First(user)
+
+	if user.ID == 0 {
+		ErrorNotFound("user does not exist", c)
+		return
+	}
+
+	Success(models.GetUserTokenLocks(user.Address, dbi), c)
+}
+
+// GetTransfers lists the token transfers of the user, 20 per page. The
+// type query limits them to mint, burn, incentive or user transfers.
+func (userCtrl *UserController) GetTransfers(c *gin.Context) {
+	addr := c.Param("address")
+	if addr == "" {
+		Error("invalid parameters", c)
+		return
+	}
+
+	var classification uint
+
+	if transferType := c.Query("type"); transferType != "" {
+
+		for _, t := range []uint{models.TokenTransferMint, models.TokenTransferBurn, models.TokenTransferIncentive, models.TokenTransferUser} {
+			if models.TokenTransferTypeName(t) == transferType {
+				classification = t
+			}
+		}
+
+		if classification == 0 {
+			Error("invalid transfer type", c)
+			return
+		}
+	}
+
+	Success(models.GetUserTransfers(addr, classification, queryOffset(c), 20, db.GetDb()), c)
+}
+
+func (userCtrl *UserController) ReconcileBalance(c *gin.Context) {
+	addr := c.Param("address")
+	if addr == "" {
+		Error("invalid parameters", c)
+		return
+	}
+
+	user := &models.User{ Address:addr }
+
+	dbi := db.GetDb()
+
+	dbi.Where(user).First(user)
+
+	if user.ID == 0 {
+		ErrorNotFound("user does not exist", c)
+		return
+	}
+
+	tokenContract, err := contracts.GetTokenContract()
+
+	if err != nil {
+		Error(err.Error(), c)
+		return
+	}
+
+	check, err := tokenContract.CheckBalance(user, dbi)
+
+	if err != nil {
+		Error(err.Error(), c)
+		return
+	}
+
+	if check.HasDrift {
+		log.Println("balance drift detected for " + user.Address + ": " + check.Drift.String())
+	}
+
+	Success(check, c)
+}
+
+// Burn submits a token burn signed by the user. The timestamp must be
+// recent and later than the one of the previous burn request of the user,
+// the user contract verifies the signature.
 func (userCtrl *UserController) Burn (c *gin.Context) {
 
 	addr := c.Param("address")
@@ -284,6 +457,20 @@ func (userCtrl *UserController) Burn (c *gin.Context) {
 		return
 	}
 
+	// The user contract verifies signatures of the bare Keccak256 hash with
+	// a recovery ID of 0 or 1, which is also the one encoding recorded
+
+	sigBytes, err := crypto.ContractSignature(signature)
+
+	if err != nil {
+		Error(err.Error(), c)
+		return
+	}
+
+	signature = hex.EncodeToString(sigBytes)
+
+	// Validate user signature against the contract
+
 	userContract, err := contracts.GetUserContract()
 
 	if err != nil {
@@ -291,12 +478,52 @@ func (userCtrl *UserController) Burn (c *gin.Context) {
 		return
 	}
 
-	if err := userContract.Burn(timestamp, addr, signature); err != nil {
+	if err := userContract.CheckBurn(timestamp, addr, signature); err != nil {
+		Error(err.Error(), c)
+		return
+	}
+
+	// Record the request before submitting so that the signature cannot
+	// be submitted twice
+
+	dbi := db.GetDb()
+
+	request, err := models.CreateBurnRequest(addr, timestamp, signature, dbi)
+
+	if err != nil {
+		Error(err.Error(), c)
+		return
+	}
+
+	txHash, err := userContract.Burn(timestamp, addr, signature)
+
+	request.UpdatedAt = uint(time.Now().Unix())
+
+	if err != nil {
+		request.Status = models.BurnRequestFailed
+		request.LastError = err.Error()
+		dbi.Save(request)
+
 		Error(err.Error(), c)
 		return
 	}
 
-	Success(nil, c)
+	request.TxHash = txHash
+
+	dbi.Save(request)
+
+	Success(request, c)
+}
+
+// GetBurns lists the burn requests of the user and their status.
+func (userCtrl *UserController) GetBurns (c *gin.Context) {
+	addr := c.Param("address")
+	if addr == "" {
+		Error("invalid parameters", c)
+		return
+	}
+
+	Success(models.GetBurnRequests(addr, queryOffset(c), db.GetDb()), c)
 }
 
 func (userCtrl *UserController) GetHP (c *gin.Context) {
@@ -317,6 +544,39 @@ func (userCtrl *UserController) GetHP (c *gin.Context) {
 		return
 	}
 
-	hp := user.GetHP(dbi).String()
+	now := uint64(time.Now().Unix())
+
+	if c.Query("explain") == "1" {
+		Success(user.ExplainHP(now, dbi), c)
+		return
+	}
+
+	hp := user.GetHP(now, dbi).String()
 	Success(hp, c)
 }
+
+// GetHPHistory returns the daily HP snapshots of the user, latest first.
+func (userCtrl *UserController) GetHPHistory (c *gin.Context) {
+	addr := c.Param("address")
+	if addr == "" {
+		Error("invalid parameters", c)
+		return
+	}
+
+	offsetNum := 0
+	offset := c.Query("offset")
+
+	if offset != "" {
+		if num, err := strconv.Atoi(offset); err == nil {
+			offsetNum = num
+		}
+	}
+
+	var history []models.HPHistory
+
+	in := db.GetDb().Where(&models.HPHistory{ UserAddress: addr })
+	in = in.Order("date desc").Offset(offsetNum).Limit(30)
+	in.Find(&history)
+
+	Success(history, c)
+}
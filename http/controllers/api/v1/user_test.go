@@ -23,6 +23,7 @@ import (
 	"github.com/primasio/primas-node/db"
 	"github.com/ethereum/go-ethereum/crypto"
 	"encoding/hex"
+	"encoding/json"
 	"net/url"
 	"strings"
 	"net/http"
@@ -56,11 +57,15 @@ func TestUpdateUser(t *testing.T) {
 
 	signature := hex.EncodeToString(sigBytes)
 
+	nameSigBytes, err := crypto.Sign(crypto.Keccak256([]byte(user.Name)), pk)
+	assert.Equal(t, err, nil)
+
 	data := url.Values{}
 	data.Set("Address", user.Address)
 	data.Set("Name", user.Name)
 	data.Set("Extra", user.Extra)
 	data.Set("Signature", signature)
+	data.Set("NameSignature", hex.EncodeToString(nameSigBytes))
 
 	req, _ := http.NewRequest("POST", "/v1/users", strings.NewReader(data.Encode()))
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
@@ -127,11 +132,15 @@ func TestCreateUser(t *testing.T) {
 
 	signature := hex.EncodeToString(sigBytes)
 
+	nameSigBytes, err := crypto.Sign(crypto.Keccak256([]byte(user.Name)), pk)
+	assert.Equal(t, err, nil)
+
 	data := url.Values{}
 	data.Set("Address", user.Address)
 	data.Set("Name", user.Name)
 	data.Set("Extra", user.Extra)
 	data.Set("Signature", signature)
+	data.Set("NameSignature", hex.EncodeToString(nameSigBytes))
 
 	req, _ := http.NewRequest("POST", "/v1/users", strings.NewReader(data.Encode()))
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
@@ -347,7 +356,7 @@ func TestUserHP (t *testing.T) {
 		assert.Equal(t, err, nil)
 		dbi.Save(articleComment)
 
-		models.CommentArticleIncentive(articleComment, dbi)
+		models.CommentArticleIncentive(articleComment, 0, uint64(time.Now().Unix()), dbi)
 
 		req, _ := http.NewRequest("GET", "/v1/users/" + user.Address + "/hp", nil)
 
@@ -372,4 +381,46 @@ func TestNonExistUserHP (t *testing.T) {
 
 	log.Println(w.Body.String())
 	assert.Equal(t, w.Code, 404)
-}
\ No newline at end of file
+}
+
+func TestUserHPExplain (t *testing.T) {
+	tests.InitTestEnv("../../../../config/")
+	router := server.NewRouter()
+	w := httptest.NewRecorder()
+
+	user, _, err := tests.CreateTestUser()
+	assert.Equal(t, err, nil)
+
+	user.Balance, err = decimal.NewFromString("2000000000000000000000")
+	assert.Equal(t, err, nil)
+
+	db.GetDb().Save(user)
+
+	req, _ := http.NewRequest("GET", "/v1/users/" + user.Address + "/hp?explain=1", nil)
+
+	router.ServeHTTP(w, req)
+
+	log.Println(w.Body.String())
+	assert.Equal(t, w.Code, 200)
+
+	// No operations yet: 2000e18 / (5 + 0)^2 / 1e18
+
+	var response struct {
+		Success bool
+		Data    string
+	}
+
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, response.Success, true)
+
+	explanation := &models.HPExplanation{}
+
+	err = json.Unmarshal([]byte(response.Data), explanation)
+	assert.Equal(t, err, nil)
+
+	assert.Equal(t, explanation.UserAddress, user.Address)
+	assert.Equal(t, explanation.OperationCount, 0)
+	assert.Equal(t, explanation.Denominator, "25")
+	assert.Equal(t, explanation.HP, "80")
+}
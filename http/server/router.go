@@ -19,6 +19,7 @@ package server
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/primasio/primas-node/http/controllers/api/v1"
+	"github.com/primasio/primas-node/http/middlewares"
 )
 
 func NewRouter() *gin.Engine {
@@ -35,19 +36,29 @@ func NewRouter() *gin.Engine {
 		articleInteractCtrl := new(v1.ArticleInteractController)
 		groupCtrl := new(v1.GroupController)
 		incentiveCtrl := new(v1.IncentiveController)
+		nodeCtrl := new(v1.NodeController)
+		jobCtrl := new(v1.JobController)
+		tokenCtrl := new(v1.TokenController)
+		signatureCtrl := new(v1.SignatureController)
 
 		userGroup := v1g.Group("users")
 		{
 			userGroup.POST("", userCtrl.Update)
 			userGroup.GET("/:address", userCtrl.Get)
+			userGroup.GET("/:address/profile/history", userCtrl.GetProfileHistory)
 			userGroup.GET("/:address/groups", userCtrl.GetGroups)
 			userGroup.GET("/:address/groups/:dna/articles", userCtrl.GetGroupArticles)
 			userGroup.GET("/:address/articles", userCtrl.GetArticles)
 			userGroup.GET("/:address/balance", userCtrl.GetBalance)
 			userGroup.GET("/:address/balance/locked", userCtrl.GetLockedBalance)
+			userGroup.GET("/:address/balance/reconcile", userCtrl.ReconcileBalance)
+			userGroup.GET("/:address/locks", userCtrl.GetLocks)
+			userGroup.GET("/:address/transfers", userCtrl.GetTransfers)
 			userGroup.GET("/:address/hp", userCtrl.GetHP)
+			userGroup.GET("/:address/hp/history", userCtrl.GetHPHistory)
 
 			userGroup.POST("/:address/burn", userCtrl.Burn)
+			userGroup.GET("/:address/burns", userCtrl.GetBurns)
 		}
 
 		articleGroup := v1g.Group("articles")
@@ -84,6 +95,44 @@ func NewRouter() *gin.Engine {
 		{
 			incentiveGroup.GET("", incentiveCtrl.List)
 			incentiveGroup.GET("/users/:address/total", incentiveCtrl.GetUserTotalIncentive)
+			incentiveGroup.GET("/users/:address/statement", incentiveCtrl.GetUserStatement)
+			incentiveGroup.GET("/runs/:id", incentiveCtrl.GetRun)
+		}
+
+		tokenGroup := v1g.Group("token")
+		{
+			tokenGroup.GET("/allowances", tokenCtrl.GetAllowances)
+			tokenGroup.GET("/admins", tokenCtrl.GetAdminHistory)
+		}
+
+		signatureGroup := v1g.Group("signatures")
+		{
+			signatureGroup.GET("/domains/:contract", signatureCtrl.GetDomain)
+			signatureGroup.GET("/nonces/:address", signatureCtrl.GetNonce)
+		}
+
+		nodeGroup := v1g.Group("nodes")
+		{
+			nodeGroup.GET("", nodeCtrl.List)
+			nodeGroup.GET("/:address", nodeCtrl.Get)
+		}
+
+		adminGroup := v1g.Group("admin")
+		adminGroup.Use(middlewares.AuthMiddleware())
+		{
+			adminGroup.GET("/incentives/simulate", incentiveCtrl.Simulate)
+			adminGroup.POST("/incentives/runs/:id/revert", incentiveCtrl.RevertRun)
+			adminGroup.GET("/incentives/flags", incentiveCtrl.ListFlags)
+			adminGroup.GET("/incentives/batches", incentiveCtrl.ListBatches)
+
+			adminGroup.GET("/jobs", jobCtrl.List)
+			adminGroup.GET("/jobs/:name/runs", jobCtrl.GetRuns)
+			adminGroup.POST("/jobs/:name/trigger", jobCtrl.Trigger)
+			adminGroup.POST("/jobs/:name/pause", jobCtrl.Pause)
+			adminGroup.POST("/jobs/:name/resume", jobCtrl.Resume)
+
+			adminGroup.POST("/articles/:dna/delete", articleCtrl.Delete)
+			adminGroup.POST("/groups/:dna/delete", groupCtrl.Delete)
 		}
 	}
 
@@ -19,94 +19,139 @@ package incentives
 import (
 	"github.com/primasio/primas-node/models"
 	"github.com/jinzhu/gorm"
-	"math"
 	"math/big"
 	"github.com/shopspring/decimal"
+	"strconv"
+	"time"
 )
 
-func DistributeIncentives(totalIncentivesToday *big.Int, db *gorm.DB) {
+// DistributeIncentives splits the inflation of the day according to the
+// configured incentive policy and calculates the incentive records. A run
+// is recorded per inflation transaction. Distributing the same inflation
+// again returns the calculated run, a run left unfinished by a crash is
+// reverted and calculated again.
+func DistributeIncentives(totalIncentivesToday *big.Int, txHash string, blockNumber uint64, blockTime uint64, db *gorm.DB) (*models.IncentiveRun, error) {
 
-	mul4 := totalIncentivesToday.Mul(big.NewInt(4), totalIncentivesToday)
+	policy, err := LoadPolicy()
 
-	// Lock current pending incentive records
+	if err != nil {
+		return nil, err
+	}
 
-	in := db.Table("incentives").Where("status = ?", models.IncentivesPending)
-	in.Updates(map[string]interface{}{"status": models.IncentivesCalculating})
+	return DistributeIncentivesWithPolicy(totalIncentivesToday, txHash, blockNumber, blockTime, policy, db)
+}
 
-	percent40 := mul4.Div(mul4, big.NewInt(10))
+func DistributeIncentivesWithPolicy(totalIncentivesToday *big.Int, txHash string, blockNumber uint64, blockTime uint64, policy *Policy, db *gorm.DB) (*models.IncentiveRun, error) {
 
-	// Calculate incentive values
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
 
-	// Fix amount of distribution for now
+	if txHash != "" {
 
-	fixedAmount := new(big.Int)
-	fixedAmount.SetString("10800000000000000000000", 10)
+		previous := &models.IncentiveRun{}
 
-	calculateArticleIncentivesForToday(fixedAmount, db)                                   // 40% for articles
-	calculateGroupIncentivesForToday(percent40, db)                                     // 40% for groups
-	calculateNodeIncentivesForToday(percent40.Div(percent40, big.NewInt(2)), db)     // 20% for nodes
-}
+		in := db.Set("gorm:query_option", "FOR UPDATE").Where("tx_hash = ?", txHash)
+		in = in.Where("status <> ?", models.IncentiveRunReverted)
+		in.First(previous)
 
-func calculateArticleIncentivesForToday(totalIncentivesAmount *big.Int, db *gorm.DB) {
+		if previous.ID != 0 {
 
-	// Align article score according to Zipf's law
+			if previous.Status == models.IncentiveRunCalculated {
+				return previous, nil
+			}
 
-	batchSize := 200
-	currentBatchOffset := 0
-	currentRank := 0
-	currentRankScore := big.NewInt(0)
-	totalScore := big.NewInt(0)
+			if err := previous.Revert(db); err != nil {
+				return nil, err
+			}
+		}
+	}
 
-	for {
-		var incentives []models.Incentive
+	articleAmount, groupAmount, nodeAmount := policy.Split(totalIncentivesToday)
 
-		in := db.Table("incentives").Where("status = ?", models.IncentivesCalculating)
-		in = in.Where("incentive_type = ?", models.IncentiveFromArticle)
-		in = in.Where("score <> 0")
-		in = in.Order("score desc").Offset(currentBatchOffset).Limit(batchSize)
-		in.Find(&incentives)
+	run := &models.IncentiveRun{}
+	run.CreatedAt = uint(time.Now().Unix())
+	run.UpdatedAt = run.CreatedAt
+	run.TxHash = txHash
+	run.BlockNumber = blockNumber
+	run.BlockTime = blockTime
+	run.PolicyVersion = policy.Version
+	run.Ranking = policy.RankingName()
+	run.TotalIncentives = decimal.NewFromBigInt(totalIncentivesToday, 0)
+	run.ArticleAmount = decimal.NewFromBigInt(articleAmount, 0)
+	run.GroupAmount = decimal.NewFromBigInt(groupAmount, 0)
+	run.NodeAmount = decimal.NewFromBigInt(nodeAmount, 0)
+	run.DistributedAmount = decimal.Zero
+	run.Status = models.IncentiveRunCalculating
 
-		if len(incentives) == 0 {
-			break
-		}
+	db.Save(run)
 
-		for _, incentive := range incentives {
+	// Lock current pending incentive records. The score is kept so that
+	// the run can be reverted.
 
-			incentiveScore := incentive.Score.Coefficient()
+	in := db.Table("incentives").Where("status = ?", models.IncentivesPending)
+	in.Updates(map[string]interface{}{
+		"status": models.IncentivesCalculating,
+		"run_id": run.ID,
+		"raw_score": gorm.Expr("score") })
 
-			if currentRankScore.Cmp(big.NewInt(0)) == 0 || incentiveScore.Cmp(currentRankScore) < 0 {
-				currentRank = currentRank + 1
-				currentRankScore = incentiveScore
-			}
+	// Discount suspicious interactions
 
-			zipfCoefficient := big.NewInt(int64(math.Ceil(math.Sqrt(float64(currentRank)))))
+	filterInteractions(&policy.Filter, run, db)
 
-			incentiveScore.Div(incentiveScore, zipfCoefficient)
+	// Calculate incentive values
 
-			if incentiveScore.Cmp(big.NewInt(0)) == 0 {
-				incentive.Score = decimal.NewFromBigInt(big.NewInt(1), 0)
-			} else {
-				incentive.Score = decimal.NewFromBigInt(incentiveScore, 0)
-			}
+	if err := calculateArticleIncentivesForToday(articleAmount, policy, run, db); err != nil {
+		return nil, err
+	}
 
-			db.Save(incentive)
+	calculateGroupIncentivesForToday(groupAmount, run, db)
+	calculateNodeIncentivesForToday(nodeAmount, run, db)
+
+	run.Finish(db)
+
+	return run, nil
+}
+
+func calculateArticleIncentivesForToday(totalIncentivesAmount *big.Int, policy *Policy, run *models.IncentiveRun, db *gorm.DB) error {
+
+	// Rank article scores with the strategy of the run
+
+	strategy, err := NewRankingStrategy(policy)
+
+	if err != nil {
+		return err
+	}
+
+	totalScore := big.NewInt(0)
+
+	for _, article := range loadRankedArticles(run, db) {
+
+		score := strategy.Score(article)
 
-			totalScore = totalScore.Add(totalScore, incentiveScore)
+		if score.Sign() <= 0 {
+			score = big.NewInt(1)
 		}
 
-		currentBatchOffset = currentBatchOffset + batchSize
+		article.Incentive.Score = decimal.NewFromBigInt(score, 0)
+
+		db.Save(article.Incentive)
+
+		totalScore = totalScore.Add(totalScore, score)
 	}
 
 	// Calculate incentives
-	currentBatchOffset = 0
+
+	batchSize := 200
+	currentBatchOffset := 0
 
 	for {
 		var incentives []models.Incentive
 
-		in := db.Table("incentives").Where("status = ?", models.IncentivesCalculating)
+		in := db.Table("incentives").Where("run_id = ?", run.ID)
 		in = in.Where("incentive_type = ?", models.IncentiveFromArticle)
 		in = in.Where("score <> 0")
-		in = in.Order("score desc").Offset(currentBatchOffset).Limit(batchSize)
+		in = in.Order("score desc, id asc").Offset(currentBatchOffset).Limit(batchSize)
 		in.Find(&incentives)
 
 		if len(incentives) == 0 {
@@ -122,11 +167,18 @@ func calculateArticleIncentivesForToday(totalIncentivesAmount *big.Int, db *gorm
 			amount = amount.Mul(totalIncentivesAmount, incScore)
 			amount = amount.Div(amount, totalScore)
 
-			amountDecimal := decimal.NewFromBigInt(amount, 0)
+			// Contributors whose interactions were all discounted to zero
+			// leave their share to the author
 
-			amountDecimalToContributors := amountDecimal.Div(decimal.NewFromBigInt(big.NewInt(10), 0)).Floor()
+			contributorScore := getArticleContributorScore(incentive.ArticleDNA, run, db)
 
-			incentive.Amount = amountDecimal.Sub(amountDecimalToContributors)
+			amountToContributors := big.NewInt(0)
+
+			if contributorScore.Sign() > 0 {
+				amountToContributors = policy.ContributorShare(amount)
+			}
+
+			incentive.Amount = decimal.NewFromBigInt(new(big.Int).Sub(amount, amountToContributors), 0)
 
 			db.Save(incentive)
 
@@ -137,32 +189,44 @@ func calculateArticleIncentivesForToday(totalIncentivesAmount *big.Int, db *gorm
 			db.Save(article)
 
 			// Calculate article contributor incentives
-			calculateArticleContributorIncentives(&incentive, amountDecimalToContributors.Coefficient(), db)
+			calculateArticleContributorIncentives(&incentive, amountToContributors, contributorScore, run, db)
 		}
 
 		currentBatchOffset = currentBatchOffset + batchSize
 	}
+
+	return nil
 }
 
-func calculateArticleContributorIncentives(articleIncentive *models.Incentive, totalAmount *big.Int, db *gorm.DB) {
+// getArticleContributorScore sums the scores of the likes, comments and
+// shares of an article in the run.
+func getArticleContributorScore(articleDNA string, run *models.IncentiveRun, db *gorm.DB) *big.Int {
 
 	totalScore := &models.TotalScore{}
 
-	in := db.Table("incentives").Where("status = ?", models.IncentivesCalculating)
+	in := db.Table("incentives").Where("run_id = ?", run.ID)
 	in = in.Where("incentive_type in (?)",[]int{models.IncentiveFromLike, models.IncentiveFromComment, models.IncentiveFromShare})
-	in = in.Where("article_dna = ?", articleIncentive.ArticleDNA)
+	in = in.Where("article_dna = ?", articleDNA)
 	in = in.Select("SUM(score) as score")
 
 	in.Scan(totalScore)
 
+	return totalScore.Score.Coefficient()
+}
+
+func calculateArticleContributorIncentives(articleIncentive *models.Incentive, totalAmount *big.Int, totalScoreInt *big.Int, run *models.IncentiveRun, db *gorm.DB) {
+
+	if totalScoreInt.Sign() == 0 {
+		return
+	}
+
 	batchSize := 200
 	currentBatchOffset := 0
-	totalScoreInt := totalScore.Score.Coefficient()
 
 	for {
 		var incentives []models.Incentive
 
-		in := db.Table("incentives").Where("status = ?", models.IncentivesCalculating)
+		in := db.Table("incentives").Where("run_id = ?", run.ID)
 		in = in.Where("incentive_type in (?)",[]int{models.IncentiveFromLike, models.IncentiveFromComment, models.IncentiveFromShare})
 		in = in.Where("article_dna = ?", articleIncentive.ArticleDNA)
 		in = in.Order("created_at desc").Offset(currentBatchOffset).Limit(batchSize)
@@ -190,10 +254,163 @@ func calculateArticleContributorIncentives(articleIncentive *models.Incentive, t
 	}
 }
 
-func calculateGroupIncentivesForToday(totalIncentivesAmount *big.Int, db *gorm.DB) {
+func calculateGroupIncentivesForToday(totalIncentivesAmount *big.Int, run *models.IncentiveRun, db *gorm.DB) {
+
+	// Group score is the weighted HP of the interactions made in the group:
+	// articles shared to it, and likes and comments made by its members
+
+	var groupScores []models.GroupScore
+
+	weights := models.GetScoreWeights()
+
+	scoreExpr := "SUM(CASE incentive_type" +
+		" WHEN " + strconv.Itoa(models.IncentiveFromLike) + " THEN score * " + strconv.FormatInt(weights.Like, 10) +
+		" WHEN " + strconv.Itoa(models.IncentiveFromComment) + " THEN score * " + strconv.FormatInt(weights.Comment, 10) +
+		" WHEN " + strconv.Itoa(models.IncentiveFromShare) + " THEN score * " + strconv.FormatInt(weights.Share, 10) +
+		" END)"
+
+	in := db.Table("incentives").Where("run_id = ?", run.ID)
+	in = in.Where("incentive_type in (?)", []int{models.IncentiveFromLike, models.IncentiveFromComment, models.IncentiveFromShare})
+	in = in.Where("group_dna <> ''")
+	in = in.Select("group_dna, COUNT(*) AS count, " + scoreExpr + " AS score")
+	in = in.Group("group_dna")
+	in.Scan(&groupScores)
+
+	totalScore := big.NewInt(0)
+
+	for _, groupScore := range groupScores {
+		totalScore = totalScore.Add(totalScore, groupScore.Score.Coefficient())
+	}
+
+	if totalScore.Cmp(big.NewInt(0)) == 0 {
+		return
+	}
+
+	for _, groupScore := range groupScores {
+
+		score := groupScore.Score.Coefficient()
+
+		if score.Cmp(big.NewInt(0)) == 0 {
+			continue
+		}
+
+		group := &models.Group{ DNA: groupScore.GroupDNA }
+
+		db.Where(group).First(group)
+
+		if group.ID == 0 {
+			continue
+		}
+
+		amount := new(big.Int).Mul(totalIncentivesAmount, score)
+		amount = amount.Div(amount, totalScore)
+
+		amountDecimal := decimal.NewFromBigInt(amount, 0)
+
+		groupIncentive := &models.GroupIncentive{}
+		groupIncentive.CreatedAt = uint(time.Now().Unix())
+		groupIncentive.GroupDNA = group.DNA
+		groupIncentive.Amount = amountDecimal
+		groupIncentive.Status = models.IncentivesCalculating
+		groupIncentive.AvgCount = groupScore.Count
+		groupIncentive.AvgScore = groupScore.Score.Div(decimal.New(int64(groupScore.Count), 0)).Floor()
+		groupIncentive.RunID = run.ID
+
+		db.Save(groupIncentive)
+
+		// Group incentives are paid to the group owner
+
+		ownerIncentive := &models.Incentive{}
+		ownerIncentive.CreatedAt = uint(time.Now().Unix())
+		ownerIncentive.IncentiveType = models.IncentiveFromGroup
+		ownerIncentive.UserAddress = group.UserAddress
+		ownerIncentive.GroupDNA = group.DNA
+		ownerIncentive.Amount = amountDecimal
+		ownerIncentive.Score = groupScore.Score
+		ownerIncentive.Status = models.IncentivesCalculating
+		ownerIncentive.RunID = run.ID
 
+		db.Set("gorm:save_associations", false).Save(ownerIncentive)
+	}
 }
 
-func calculateNodeIncentivesForToday(totalIncentivesAmount *big.Int, db *gorm.DB) {
+func calculateNodeIncentivesForToday(totalIncentivesAmount *big.Int, run *models.IncentiveRun, db *gorm.DB) {
+
+	// Lock content transactions relayed by nodes since last distribution
+
+	in := db.Table("node_activities").Where("status = ?", models.IncentivesPending)
+	in.Updates(map[string]interface{}{"status": models.IncentivesCalculating, "run_id": run.ID})
+
+	// Node activity is the weighted count of relayed transactions, leaving
+	// out articles referencing a block too far behind, which a node not
+	// synchronized with the chain relayed. Node health is the share of block
+	// buckets of the period in which the node relayed at least one
+	// transaction. Both only depend on chain data so every node calculates
+	// the same incentives.
+
+	bucket := "FLOOR(block_number / " + strconv.Itoa(models.NodeActivityBucketBlocks) + ")"
+
+	var nodeScores []models.NodeScore
+
+	in = db.Table("node_activities").Where("run_id = ?", run.ID)
+	in = in.Select("node_address" +
+		", SUM(CASE WHEN activity_type = " + strconv.Itoa(models.NodeActivityArticle) + " AND sync_lag <= " + strconv.Itoa(models.NodeMaxSyncLagBlocks) + " THEN 1 ELSE 0 END) AS article_count" +
+		", SUM(CASE activity_type WHEN " + strconv.Itoa(models.NodeActivityInteraction) + " THEN 1 ELSE 0 END) AS interaction_count" +
+		", COUNT(DISTINCT " + bucket + ") AS active_buckets")
+	in = in.Group("node_address")
+	in.Scan(&nodeScores)
+
+	var bucketRange struct {
+		MinBucket uint64
+		MaxBucket uint64
+	}
+
+	in = db.Table("node_activities").Where("run_id = ?", run.ID)
+	in = in.Select("MIN(" + bucket + ") AS min_bucket, MAX(" + bucket + ") AS max_bucket")
+	in.Scan(&bucketRange)
+
+	totalBuckets := big.NewInt(int64(bucketRange.MaxBucket - bucketRange.MinBucket + 1))
+
+	activities := make([]*big.Int, len(nodeScores))
+	totalActivity := big.NewInt(0)
+
+	for i, nodeScore := range nodeScores {
+
+		activity := big.NewInt(int64(nodeScore.ArticleCount * models.NodeArticleWeight))
+		activity = activity.Add(activity, big.NewInt(int64(nodeScore.InteractionCount * models.NodeInteractionWeight)))
+
+		activities[i] = activity
+		totalActivity = totalActivity.Add(totalActivity, activity)
+	}
+
+	if totalActivity.Cmp(big.NewInt(0)) != 0 {
+
+		for i, nodeScore := range nodeScores {
+
+			// Share of activity, reduced by the node health
+
+			amount := new(big.Int).Mul(totalIncentivesAmount, activities[i])
+			amount = amount.Mul(amount, big.NewInt(int64(nodeScore.ActiveBuckets)))
+			amount = amount.Div(amount, totalActivity)
+			amount = amount.Div(amount, totalBuckets)
+
+			if amount.Cmp(big.NewInt(0)) == 0 {
+				continue
+			}
+
+			nodeIncentive := &models.Incentive{}
+			nodeIncentive.CreatedAt = uint(time.Now().Unix())
+			nodeIncentive.IncentiveType = models.IncentiveFromNode
+			nodeIncentive.UserAddress = nodeScore.NodeAddress
+			nodeIncentive.Amount = decimal.NewFromBigInt(amount, 0)
+			nodeIncentive.Score = decimal.NewFromBigInt(activities[i], 0)
+			nodeIncentive.Status = models.IncentivesCalculating
+			nodeIncentive.RunID = run.ID
+
+			db.Set("gorm:save_associations", false).Save(nodeIncentive)
+		}
+	}
 
+	in = db.Table("node_activities").Where("run_id = ?", run.ID)
+	in.Updates(map[string]interface{}{"status": models.IncentivesPaid})
 }
@@ -26,6 +26,7 @@ import (
 	"github.com/primasio/primas-node/cron"
 	"math/big"
 	"github.com/shopspring/decimal"
+	"time"
 )
 
 func TestArticleScoreCalculation (t *testing.T) {
@@ -176,11 +177,11 @@ func TestArticleScoreCalculation (t *testing.T) {
 
 	// Add some article incentives
 
-	models.ShareArticleIncentive(groupArticle1, dbi)
-	models.ShareArticleIncentive(groupArticle2, dbi)
-	models.ShareArticleIncentive(groupArticle3, dbi)
-	models.ShareArticleIncentive(groupArticle4, dbi)
-	models.ShareArticleIncentive(groupArticle5, dbi)
+	models.ShareArticleIncentive(groupArticle1, 0, uint64(time.Now().Unix()), dbi)
+	models.ShareArticleIncentive(groupArticle2, 0, uint64(time.Now().Unix()), dbi)
+	models.ShareArticleIncentive(groupArticle3, 0, uint64(time.Now().Unix()), dbi)
+	models.ShareArticleIncentive(groupArticle4, 0, uint64(time.Now().Unix()), dbi)
+	models.ShareArticleIncentive(groupArticle5, 0, uint64(time.Now().Unix()), dbi)
 
 	//models.LikeArticleIncentive(likeA1U1, dbi)
 	//models.LikeArticleIncentive(likeA2U1, dbi)
@@ -201,12 +202,70 @@ func TestArticleIncentivesDistribution (t *testing.T) {
 
 	dbi := db.GetDb()
 
-	incentives.DistributeIncentives(totalIncentives, dbi)
+	incentives.DistributeIncentives(totalIncentives, "", 0, uint64(time.Now().Unix()), dbi)
+}
+
+func TestArticleIncentivesWithoutContributorScore (t *testing.T) {
+
+	tests.InitTestEnv("../config/")
+
+	dbi := db.GetDb()
+
+	owner, _, err := tests.CreateTestUser()
+	assert.Equal(t, err, nil)
+
+	owner.Balance, err = decimal.NewFromString("100000000000000000000")
+	assert.Equal(t, err, nil)
+
+	article, err := tests.CreateTestArticle(owner)
+	assert.Equal(t, err, nil)
+
+	group, err := tests.CreateTestGroup(owner)
+	assert.Equal(t, err, nil)
+
+	dbi.Save(owner)
+	dbi.Save(article)
+	dbi.Save(group)
+
+	// The only interaction is the author sharing their own article, which
+	// the filter discounts to zero
+
+	groupArticle, err := tests.CreateGroupArticle(article, group, owner)
+	assert.Equal(t, err, nil)
+
+	dbi.Save(groupArticle)
+
+	models.ShareArticleIncentive(groupArticle, 0, uint64(time.Now().Unix()), dbi)
+
+	totalIncentives := big.NewInt(0)
+	totalIncentives.SetString("200000000000000000000000", 10)
+
+	run, err := incentives.DistributeIncentives(totalIncentives, "", 0, uint64(time.Now().Unix()), dbi)
+	assert.Equal(t, err, nil)
+
+	share := &models.Incentive{}
+	dbi.Where(&models.Incentive{ RunID: run.ID, ArticleDNA: article.DNA, IncentiveType: models.IncentiveFromShare }).First(share)
+
+	assert.Equal(t, share.ID != 0, true)
+	assert.Equal(t, share.Score.Sign(), 0)
+	assert.Equal(t, share.Amount.Sign(), 0)
+
+	// The author keeps the share of the contributors
+
+	authorIncentive := &models.Incentive{}
+	dbi.Where(&models.Incentive{ RunID: run.ID, ArticleDNA: article.DNA, IncentiveType: models.IncentiveFromArticle }).First(authorIncentive)
+
+	assert.Equal(t, authorIncentive.ID != 0, true)
+	assert.Equal(t, authorIncentive.Amount.Sign(), 1)
+
+	dbi.Where(&models.Article{ DNA: article.DNA }).First(article)
+
+	assert.Equal(t, article.TotalIncentives.String(), authorIncentive.Amount.String())
 }
 
 func TestInflate (t *testing.T) {
 
 	tests.InitTestEnv("../config/")
 
-	cron.TriggerInflation()
+	cron.TriggerInflation(nil)
 }
\ No newline at end of file
@@ -0,0 +1,331 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package incentives
+
+import (
+	"math/big"
+	"sort"
+	"strconv"
+	"time"
+	"github.com/jinzhu/gorm"
+	"github.com/primasio/primas-node/models"
+	"github.com/shopspring/decimal"
+)
+
+// FilterPolicy describes how suspicious interactions are discounted. A
+// discount is the percentage of the interaction score taken away. Burst
+// detection is disabled when BurstMaxInteractions is 0.
+type FilterPolicy struct {
+	SelfInteractionDiscount int64
+	RingDiscount            int64
+	BurstDiscount           int64
+	BurstWindowHours        int64
+	BurstMaxInteractions    int
+}
+
+// Interaction is a like, comment or share taking part in a run. BlockTime
+// is the timestamp of the block of its event.
+type Interaction struct {
+	ID            uint
+	BlockTime     uint64
+	IncentiveType uint
+	UserAddress   string
+	ArticleDNA    string
+	AuthorAddress string
+	Score         decimal.Decimal `gorm:"type:decimal(65)"`
+}
+
+type Flag struct {
+	Interaction *Interaction
+	FlagType    uint
+	Discount    int64
+	Details     string
+}
+
+// DetectFlags returns the flags raised by the interactions.
+func DetectFlags(interactions []*Interaction, filter *FilterPolicy) []*Flag {
+
+	var flags []*Flag
+
+	// Self interactions
+
+	for _, interaction := range interactions {
+		if interaction.UserAddress == interaction.AuthorAddress {
+			flags = append(flags, &Flag{
+				Interaction: interaction,
+				FlagType: models.IncentiveFlagSelfInteraction,
+				Discount: filter.SelfInteractionDiscount,
+				Details: "interaction with own article" })
+		}
+	}
+
+	// Rings are groups of accounts reaching each other through
+	// interactions with each other's articles
+
+	rings := findRings(interactions)
+
+	for _, interaction := range interactions {
+
+		if interaction.UserAddress == interaction.AuthorAddress {
+			continue
+		}
+
+		userRing, ok := rings[interaction.UserAddress]
+
+		if ok && userRing == rings[interaction.AuthorAddress] {
+			flags = append(flags, &Flag{
+				Interaction: interaction,
+				FlagType: models.IncentiveFlagRing,
+				Discount: filter.RingDiscount,
+				Details: "ring of " + strconv.Itoa(userRing.size) + " accounts" })
+		}
+	}
+
+	// Bursts are interactions of an account beyond the allowed count
+	// within the window
+
+	if filter.BurstMaxInteractions > 0 {
+
+		window := uint64(filter.BurstWindowHours * int64(time.Hour / time.Second))
+
+		userInteractions := make(map[string][]*Interaction)
+
+		for _, interaction := range interactions {
+			userInteractions[interaction.UserAddress] = append(userInteractions[interaction.UserAddress], interaction)
+		}
+
+		for _, list := range userInteractions {
+
+			sort.Slice(list, func(i, j int) bool {
+				if list[i].BlockTime == list[j].BlockTime {
+					return list[i].ID < list[j].ID
+				}
+				return list[i].BlockTime < list[j].BlockTime
+			})
+
+			start := 0
+
+			for end, interaction := range list {
+
+				for start < end && interaction.BlockTime - list[start].BlockTime >= window {
+					start = start + 1
+				}
+
+				if end - start + 1 > filter.BurstMaxInteractions {
+					flags = append(flags, &Flag{
+						Interaction: interaction,
+						FlagType: models.IncentiveFlagBurst,
+						Discount: filter.BurstDiscount,
+						Details: strconv.Itoa(end - start + 1) + " interactions within " + strconv.FormatInt(filter.BurstWindowHours, 10) + " hours" })
+				}
+			}
+		}
+	}
+
+	return flags
+}
+
+type ring struct {
+	id   int
+	size int
+}
+
+// findRings returns the strongly connected components of more than one
+// account of the interaction graph, keyed by account.
+func findRings(interactions []*Interaction) map[string]*ring {
+
+	edges := make(map[string][]string)
+
+	for _, interaction := range interactions {
+		if interaction.UserAddress != interaction.AuthorAddress {
+			edges[interaction.UserAddress] = append(edges[interaction.UserAddress], interaction.AuthorAddress)
+		}
+	}
+
+	// Tarjan's algorithm
+
+	index := 0
+	indexes := make(map[string]int)
+	lowLinks := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+
+	rings := make(map[string]*ring)
+	ringCount := 0
+
+	var connect func(account string)
+
+	connect = func(account string) {
+
+		indexes[account] = index
+		lowLinks[account] = index
+		index = index + 1
+
+		stack = append(stack, account)
+		onStack[account] = true
+
+		for _, next := range edges[account] {
+			if _, visited := indexes[next]; !visited {
+				connect(next)
+				if lowLinks[next] < lowLinks[account] {
+					lowLinks[account] = lowLinks[next]
+				}
+			} else if onStack[next] && indexes[next] < lowLinks[account] {
+				lowLinks[account] = indexes[next]
+			}
+		}
+
+		if lowLinks[account] != indexes[account] {
+			return
+		}
+
+		var members []string
+
+		for {
+			member := stack[len(stack) - 1]
+			stack = stack[:len(stack) - 1]
+			onStack[member] = false
+			members = append(members, member)
+
+			if member == account {
+				break
+			}
+		}
+
+		if len(members) > 1 {
+
+			ringCount = ringCount + 1
+
+			found := &ring{ id: ringCount, size: len(members) }
+
+			for _, member := range members {
+				rings[member] = found
+			}
+		}
+	}
+
+	var accounts []string
+
+	for account := range edges {
+		accounts = append(accounts, account)
+	}
+
+	sort.Strings(accounts)
+
+	for _, account := range accounts {
+		if _, visited := indexes[account]; !visited {
+			connect(account)
+		}
+	}
+
+	return rings
+}
+
+// filterInteractions discounts the flagged interactions of the run before
+// the distribution, together with the scores of their articles.
+func filterInteractions(filter *FilterPolicy, run *models.IncentiveRun, db *gorm.DB) {
+
+	var interactions []*Interaction
+
+	in := db.Table("incentives").Joins("JOIN articles ON articles.dna = incentives.article_dna")
+	in = in.Where("incentives.run_id = ?", run.ID)
+	in = in.Where("incentives.incentive_type in (?)", []int{models.IncentiveFromLike, models.IncentiveFromComment, models.IncentiveFromShare})
+	// Interactions recorded before their block time was kept fall back to
+	// the time they were synchronized
+	in = in.Select("incentives.id" +
+		", CASE WHEN incentives.block_time = 0 THEN incentives.created_at ELSE incentives.block_time END AS block_time" +
+		", incentives.incentive_type, incentives.user_address" +
+		", incentives.article_dna, incentives.score, articles.user_address AS author_address")
+	in.Scan(&interactions)
+
+	flags := DetectFlags(interactions, filter)
+
+	if len(flags) == 0 {
+		return
+	}
+
+	weights := models.GetScoreWeights()
+
+	scores := make(map[uint]*big.Int)
+	articleDiscounts := make(map[string]*big.Int)
+
+	for _, flag := range flags {
+
+		interaction := flag.Interaction
+
+		original, ok := scores[interaction.ID]
+
+		if !ok {
+			original = interaction.Score.Coefficient()
+		}
+
+		discounted := new(big.Int).Sub(original, percentOf(original, flag.Discount))
+
+		scores[interaction.ID] = discounted
+
+		record := &models.IncentiveFlag{}
+		record.CreatedAt = uint(time.Now().Unix())
+		record.RunID = run.ID
+		record.IncentiveID = interaction.ID
+		record.FlagType = flag.FlagType
+		record.UserAddress = interaction.UserAddress
+		record.ArticleDNA = interaction.ArticleDNA
+		record.OriginalScore = decimal.NewFromBigInt(original, 0)
+		record.DiscountedScore = decimal.NewFromBigInt(discounted, 0)
+		record.Details = flag.Details
+
+		db.Save(record)
+
+		discount := new(big.Int).Sub(original, discounted)
+		discount = discount.Mul(discount, big.NewInt(weights.ForType(interaction.IncentiveType)))
+
+		if articleDiscounts[interaction.ArticleDNA] == nil {
+			articleDiscounts[interaction.ArticleDNA] = big.NewInt(0)
+		}
+
+		articleDiscounts[interaction.ArticleDNA].Add(articleDiscounts[interaction.ArticleDNA], discount)
+	}
+
+	for id, score := range scores {
+		in := db.Table("incentives").Where("id = ?", id)
+		in.Updates(map[string]interface{}{"score": decimal.NewFromBigInt(score, 0)})
+	}
+
+	for articleDNA, discount := range articleDiscounts {
+
+		articleIncentive := &models.Incentive{}
+
+		in := db.Where("run_id = ?", run.ID).Where("incentive_type = ?", models.IncentiveFromArticle)
+		in = in.Where("article_dna = ?", articleDNA)
+		in.First(articleIncentive)
+
+		if articleIncentive.ID == 0 {
+			continue
+		}
+
+		score := articleIncentive.Score.Coefficient()
+		score = score.Sub(score, discount)
+
+		if score.Sign() < 0 {
+			score = big.NewInt(0)
+		}
+
+		articleIncentive.Score = decimal.NewFromBigInt(score, 0)
+
+		db.Set("gorm:save_associations", false).Save(articleIncentive)
+	}
+}
@@ -0,0 +1,65 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package incentives_test
+
+import (
+	"testing"
+	"github.com/magiconair/properties/assert"
+	"github.com/primasio/primas-node/incentives"
+	"github.com/primasio/primas-node/models"
+)
+
+func TestDetectFlags (t *testing.T) {
+
+	filter := &incentives.FilterPolicy{
+		SelfInteractionDiscount: 100,
+		RingDiscount: 50,
+		BurstDiscount: 100,
+		BurstWindowHours: 1,
+		BurstMaxInteractions: 2 }
+
+	interactions := []*incentives.Interaction{
+		// Self like
+		{ ID: 1, BlockTime: 0, UserAddress: "a", AuthorAddress: "a" },
+
+		// Ring of a, b and c
+		{ ID: 2, BlockTime: 0, UserAddress: "a", AuthorAddress: "b" },
+		{ ID: 3, BlockTime: 0, UserAddress: "b", AuthorAddress: "c" },
+		{ ID: 4, BlockTime: 0, UserAddress: "c", AuthorAddress: "a" },
+
+		// Burst of d, the third interaction within an hour is flagged
+		{ ID: 5, BlockTime: 0, UserAddress: "d", AuthorAddress: "e" },
+		{ ID: 6, BlockTime: 600, UserAddress: "d", AuthorAddress: "e" },
+		{ ID: 7, BlockTime: 1200, UserAddress: "d", AuthorAddress: "e" },
+		{ ID: 8, BlockTime: 7200, UserAddress: "d", AuthorAddress: "e" },
+	}
+
+	flagged := make(map[uint][]uint)
+
+	for _, flag := range incentives.DetectFlags(interactions, filter) {
+		flagged[flag.Interaction.ID] = append(flagged[flag.Interaction.ID], flag.FlagType)
+	}
+
+	assert.Equal(t, flagged[1], []uint{models.IncentiveFlagSelfInteraction})
+	assert.Equal(t, flagged[2], []uint{models.IncentiveFlagRing})
+	assert.Equal(t, flagged[3], []uint{models.IncentiveFlagRing})
+	assert.Equal(t, flagged[4], []uint{models.IncentiveFlagRing})
+	assert.Equal(t, len(flagged[5]), 0)
+	assert.Equal(t, len(flagged[6]), 0)
+	assert.Equal(t, flagged[7], []uint{models.IncentiveFlagBurst})
+	assert.Equal(t, len(flagged[8]), 0)
+}
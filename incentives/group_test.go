@@ -0,0 +1,111 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package incentives_test
+
+import (
+	"testing"
+	"github.com/primasio/primas-node/incentives"
+	"github.com/primasio/primas-node/tests"
+	"github.com/magiconair/properties/assert"
+	"github.com/primasio/primas-node/db"
+	"github.com/primasio/primas-node/models"
+	"math/big"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+func TestGroupIncentivesDistribution (t *testing.T) {
+
+	tests.InitTestEnv("../config/")
+
+	dbi := db.GetDb()
+
+	owner, _, err := tests.CreateTestUser()
+	assert.Equal(t, err, nil)
+
+	article, err := tests.CreateTestArticle(owner)
+	assert.Equal(t, err, nil)
+
+	busyGroup, err := tests.CreateTestGroup(owner)
+	assert.Equal(t, err, nil)
+
+	quietGroup, err := tests.CreateTestGroup(owner)
+	assert.Equal(t, err, nil)
+
+	dbi.Save(owner)
+	dbi.Save(article)
+	dbi.Save(busyGroup)
+	dbi.Save(quietGroup)
+
+	// Members with the same HP share the article: twice in the busy group,
+	// once in the quiet group
+
+	sharedTo := []*models.Group{ busyGroup, busyGroup, quietGroup }
+
+	for _, group := range sharedTo {
+
+		member, _, err := tests.CreateTestUser()
+		assert.Equal(t, err, nil)
+
+		member.Balance, err = decimal.NewFromString("100000000000000000000")
+		assert.Equal(t, err, nil)
+
+		dbi.Save(member)
+
+		groupArticle, err := tests.CreateGroupArticle(article, group, member)
+		assert.Equal(t, err, nil)
+
+		dbi.Save(groupArticle)
+
+		models.ShareArticleIncentive(groupArticle, 0, uint64(time.Now().Unix()), dbi)
+	}
+
+	totalIncentives := big.NewInt(0)
+	totalIncentives.SetString("200000000000000000000000", 10)
+
+	_, err = incentives.DistributeIncentives(totalIncentives, "", 0, uint64(time.Now().Unix()), dbi)
+	assert.Equal(t, err, nil)
+
+	busyIncentive := &models.GroupIncentive{}
+	dbi.Where(&models.GroupIncentive{ GroupDNA: busyGroup.DNA }).First(busyIncentive)
+
+	quietIncentive := &models.GroupIncentive{}
+	dbi.Where(&models.GroupIncentive{ GroupDNA: quietGroup.DNA }).First(quietIncentive)
+
+	assert.Equal(t, busyIncentive.ID != 0, true)
+	assert.Equal(t, quietIncentive.ID != 0, true)
+
+	assert.Equal(t, busyIncentive.AvgCount, uint(2))
+	assert.Equal(t, quietIncentive.AvgCount, uint(1))
+	assert.Equal(t, busyIncentive.AvgScore.String(), quietIncentive.AvgScore.String())
+
+	// Busy group earns twice as much, up to rounding
+
+	diff := busyIncentive.Amount.Sub(quietIncentive.Amount.Mul(decimal.New(2, 0))).Abs()
+	assert.Equal(t, diff.Cmp(decimal.New(1, 0)) <= 0, true)
+
+	// Group incentives are credited to the owner
+
+	ownerIncentive := &models.Incentive{}
+	dbi.Where(&models.Incentive{
+		UserAddress: owner.Address,
+		GroupDNA: busyGroup.DNA,
+		IncentiveType: models.IncentiveFromGroup }).First(ownerIncentive)
+
+	assert.Equal(t, ownerIncentive.ID != 0, true)
+	assert.Equal(t, ownerIncentive.Amount.String(), busyIncentive.Amount.String())
+}
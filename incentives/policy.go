@@ -0,0 +1,203 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package incentives
+
+import (
+	"errors"
+	"math/big"
+	"github.com/primasio/primas-node/config"
+	"github.com/primasio/primas-node/models"
+)
+
+// In proportional mode the article pool is a percentage of the inflation,
+// in fixed mode it is a constant amount.
+const PolicyModeProportional = "proportional"
+const PolicyModeFixed = "fixed"
+
+// Policy describes how the inflation of a day is split between articles,
+// groups and nodes. The version is recorded with every distribution so
+// that past runs can be reproduced.
+type Policy struct {
+	Version            string
+	Mode               string
+	ArticlePercent     int64
+	GroupPercent       int64
+	NodePercent        int64
+	FixedArticleAmount *big.Int
+	ContributorPercent int64
+	Ranking            string
+	ZipfExponent       float64
+	HalfLifeHours      float64
+	Filter             FilterPolicy
+}
+
+// DefaultPolicy is the split of nodes older than policies: a fixed
+// article pool of 10800 tokens, 40% for groups, 20% for nodes and a tenth
+// of each article incentive for its contributors, ranked by sqrt. It is
+// recorded with the legacy version.
+func DefaultPolicy() *Policy {
+
+	amount, _ := new(big.Int).SetString("10800000000000000000000", 10)
+
+	return &Policy{
+		Version: models.LegacyPolicyVersion,
+		Mode: PolicyModeFixed,
+		GroupPercent: 40,
+		NodePercent: 20,
+		FixedArticleAmount: amount,
+		ContributorPercent: 10,
+		Ranking: RankingSqrt }
+}
+
+// LoadPolicy reads the policy from incentives.policy in the configuration,
+// DefaultPolicy is used without that section.
+func LoadPolicy() (*Policy, error) {
+
+	c := config.GetConfig()
+
+	if !c.IsSet("incentives.policy") {
+		return DefaultPolicy(), nil
+	}
+
+	policy := &Policy{}
+
+	policy.Version = c.GetString("incentives.policy.version")
+	policy.Mode = c.GetString("incentives.policy.mode")
+	policy.ArticlePercent = c.GetInt64("incentives.policy.article_percent")
+	policy.GroupPercent = c.GetInt64("incentives.policy.group_percent")
+	policy.NodePercent = c.GetInt64("incentives.policy.node_percent")
+	policy.ContributorPercent = c.GetInt64("incentives.policy.contributor_percent")
+	policy.Ranking = c.GetString("incentives.policy.ranking")
+	policy.ZipfExponent = c.GetFloat64("incentives.policy.zipf_exponent")
+	policy.HalfLifeHours = c.GetFloat64("incentives.policy.half_life_hours")
+
+	policy.Filter.SelfInteractionDiscount = c.GetInt64("incentives.policy.filter.self_interaction_discount")
+	policy.Filter.RingDiscount = c.GetInt64("incentives.policy.filter.ring_discount")
+	policy.Filter.BurstDiscount = c.GetInt64("incentives.policy.filter.burst_discount")
+	policy.Filter.BurstWindowHours = c.GetInt64("incentives.policy.filter.burst_window_hours")
+	policy.Filter.BurstMaxInteractions = c.GetInt("incentives.policy.filter.burst_max_interactions")
+
+	if policy.Mode == PolicyModeFixed {
+
+		amount, ok := new(big.Int).SetString(c.GetString("incentives.policy.fixed_article_amount"), 10)
+
+		if !ok {
+			return nil, errors.New("invalid incentives.policy.fixed_article_amount")
+		}
+
+		policy.FixedArticleAmount = amount
+	}
+
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+func (policy *Policy) Validate() error {
+
+	if policy.Version == "" {
+		return errors.New("incentive policy version is empty")
+	}
+
+	if policy.Mode != PolicyModeProportional && policy.Mode != PolicyModeFixed {
+		return errors.New("unknown incentive policy mode: " + policy.Mode)
+	}
+
+	percents := []int64{
+		policy.ArticlePercent,
+		policy.GroupPercent,
+		policy.NodePercent,
+		policy.ContributorPercent,
+		policy.Filter.SelfInteractionDiscount,
+		policy.Filter.RingDiscount,
+		policy.Filter.BurstDiscount }
+
+	for _, percent := range percents {
+		if percent < 0 || percent > 100 {
+			return errors.New("incentive policy percentages must be between 0 and 100")
+		}
+	}
+
+	total := policy.GroupPercent + policy.NodePercent
+
+	if policy.Mode == PolicyModeProportional {
+		total = total + policy.ArticlePercent
+	}
+
+	if total > 100 {
+		return errors.New("incentive policy pools exceed the inflation amount")
+	}
+
+	if policy.Mode == PolicyModeFixed && (policy.FixedArticleAmount == nil || policy.FixedArticleAmount.Sign() < 0) {
+		return errors.New("incentive policy fixed article amount is invalid")
+	}
+
+	if _, err := NewRankingStrategy(policy); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RankingName returns the name of the ranking strategy of the policy.
+func (policy *Policy) RankingName() string {
+
+	if policy.Ranking == "" {
+		return RankingSqrt
+	}
+
+	return policy.Ranking
+}
+
+// Split returns the article, group and node pools for the inflation
+// amount. In fixed mode the article pool is capped to what the group and
+// node pools leave of the inflation. The inflation amount is left
+// untouched.
+func (policy *Policy) Split(totalIncentives *big.Int) (article, group, node *big.Int) {
+
+	group = percentOf(totalIncentives, policy.GroupPercent)
+	node = percentOf(totalIncentives, policy.NodePercent)
+
+	if policy.Mode == PolicyModeFixed {
+
+		article = new(big.Int).Set(policy.FixedArticleAmount)
+
+		remaining := new(big.Int).Sub(totalIncentives, group)
+		remaining = remaining.Sub(remaining, node)
+
+		if article.Cmp(remaining) > 0 {
+			article = remaining
+		}
+	} else {
+		article = percentOf(totalIncentives, policy.ArticlePercent)
+	}
+
+	return article, group, node
+}
+
+// ContributorShare returns the part of an article incentive paid to the
+// users who liked, commented or shared the article.
+func (policy *Policy) ContributorShare(articleAmount *big.Int) *big.Int {
+	return percentOf(articleAmount, policy.ContributorPercent)
+}
+
+func percentOf(amount *big.Int, percent int64) *big.Int {
+	result := new(big.Int).Mul(amount, big.NewInt(percent))
+	return result.Div(result, big.NewInt(100))
+}
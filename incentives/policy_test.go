@@ -0,0 +1,85 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package incentives_test
+
+import (
+	"testing"
+	"math/big"
+	"github.com/magiconair/properties/assert"
+	"github.com/primasio/primas-node/incentives"
+)
+
+func TestPolicySplit (t *testing.T) {
+
+	policy := &incentives.Policy{
+		Version: "1",
+		Mode: incentives.PolicyModeProportional,
+		ArticlePercent: 40,
+		GroupPercent: 40,
+		NodePercent: 20,
+		ContributorPercent: 10 }
+
+	assert.Equal(t, policy.Validate(), nil)
+
+	total := big.NewInt(1000)
+
+	article, group, node := policy.Split(total)
+
+	assert.Equal(t, article.String(), "400")
+	assert.Equal(t, group.String(), "400")
+	assert.Equal(t, node.String(), "200")
+	assert.Equal(t, policy.ContributorShare(article).String(), "40")
+
+	// The inflation amount is not modified
+
+	assert.Equal(t, total.String(), "1000")
+
+	policy.Mode = incentives.PolicyModeFixed
+	policy.FixedArticleAmount = big.NewInt(300)
+
+	article, _, _ = policy.Split(total)
+
+	assert.Equal(t, article.String(), "300")
+
+	// Capped to what groups and nodes leave of the inflation
+
+	policy.FixedArticleAmount = big.NewInt(700)
+
+	article, _, _ = policy.Split(total)
+
+	assert.Equal(t, article.String(), "400")
+
+	policy.GroupPercent = 90
+
+	assert.Equal(t, policy.Validate() != nil, true)
+}
+
+func TestDefaultPolicy (t *testing.T) {
+
+	policy := incentives.DefaultPolicy()
+
+	assert.Equal(t, policy.Validate(), nil)
+
+	total, _ := new(big.Int).SetString("100000000000000000000000", 10)
+
+	article, group, node := policy.Split(total)
+
+	assert.Equal(t, article.String(), "10800000000000000000000")
+	assert.Equal(t, group.String(), "40000000000000000000000")
+	assert.Equal(t, node.String(), "20000000000000000000000")
+	assert.Equal(t, policy.ContributorShare(article).String(), "1080000000000000000000")
+}
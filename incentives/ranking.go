@@ -0,0 +1,232 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package incentives
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"time"
+	"github.com/jinzhu/gorm"
+	"github.com/primasio/primas-node/models"
+)
+
+const RankingSqrt = "sqrt"
+const RankingZipf = "zipf"
+const RankingTimeDecay = "time_decay"
+const RankingQuadratic = "quadratic"
+
+// RankedArticle is an article taking part in a run, ordered by score.
+type RankedArticle struct {
+	Incentive     *models.Incentive
+
+	// Weighted HP of the interactions with the article
+	Score         *big.Int
+
+	// Articles with the same score share a rank, starting at 1
+	Rank          int
+
+	// Time from the block of the publication to the block of the inflation
+	Age           time.Duration
+
+	// Weighted HP of each interaction
+	Contributions []*big.Int
+}
+
+// RankingStrategy turns the score of an article into the share it gets
+// of the article pool.
+type RankingStrategy interface {
+	Name() string
+	Score(article *RankedArticle) *big.Int
+}
+
+// NewRankingStrategy returns the ranking strategy selected by the policy.
+// The ceil(sqrt(rank)) ranking is the default.
+func NewRankingStrategy(policy *Policy) (RankingStrategy, error) {
+
+	switch policy.Ranking {
+	case "", RankingSqrt:
+		return &SqrtRanking{}, nil
+	case RankingZipf:
+		if policy.ZipfExponent <= 0 {
+			return nil, errors.New("zipf ranking needs a positive exponent")
+		}
+		return &ZipfRanking{ Exponent: policy.ZipfExponent }, nil
+	case RankingTimeDecay:
+		if policy.HalfLifeHours <= 0 {
+			return nil, errors.New("time decay ranking needs a positive half life")
+		}
+		return &TimeDecayRanking{ HalfLife: time.Duration(policy.HalfLifeHours * float64(time.Hour)) }, nil
+	case RankingQuadratic:
+		return &QuadraticRanking{}, nil
+	default:
+		return nil, errors.New("unknown ranking strategy: " + policy.Ranking)
+	}
+}
+
+// SqrtRanking divides the score by ceil(sqrt(rank)).
+type SqrtRanking struct{}
+
+func (ranking *SqrtRanking) Name() string {
+	return RankingSqrt
+}
+
+func (ranking *SqrtRanking) Score(article *RankedArticle) *big.Int {
+	coefficient := big.NewInt(int64(math.Ceil(math.Sqrt(float64(article.Rank)))))
+	return new(big.Int).Div(article.Score, coefficient)
+}
+
+// ZipfRanking divides the score by rank^exponent.
+type ZipfRanking struct {
+	Exponent float64
+}
+
+func (ranking *ZipfRanking) Name() string {
+	return RankingZipf
+}
+
+func (ranking *ZipfRanking) Score(article *RankedArticle) *big.Int {
+	return scale(article.Score, 1 / math.Pow(float64(article.Rank), ranking.Exponent))
+}
+
+// TimeDecayRanking halves the score of an article every half life since
+// its publication, regardless of its rank.
+type TimeDecayRanking struct {
+	HalfLife time.Duration
+}
+
+func (ranking *TimeDecayRanking) Name() string {
+	return RankingTimeDecay
+}
+
+func (ranking *TimeDecayRanking) Score(article *RankedArticle) *big.Int {
+	return scale(article.Score, math.Pow(0.5, float64(article.Age) / float64(ranking.HalfLife)))
+}
+
+// QuadraticRanking scores an article by the square of the sum of the
+// square roots of its interactions, so that many small interactions
+// weigh more than a few large ones.
+type QuadraticRanking struct{}
+
+func (ranking *QuadraticRanking) Name() string {
+	return RankingQuadratic
+}
+
+func (ranking *QuadraticRanking) Score(article *RankedArticle) *big.Int {
+
+	sum := big.NewInt(0)
+
+	for _, contribution := range article.Contributions {
+		if contribution.Sign() > 0 {
+			sum = sum.Add(sum, new(big.Int).Sqrt(contribution))
+		}
+	}
+
+	return sum.Mul(sum, sum)
+}
+
+func scale(value *big.Int, factor float64) *big.Int {
+	result := new(big.Float).SetInt(value)
+	result = result.Mul(result, big.NewFloat(factor))
+
+	scaled, _ := result.Int(nil)
+
+	return scaled
+}
+
+// loadRankedArticles returns the article incentives of the run ordered by
+// score, with their rank, age and interactions.
+func loadRankedArticles(run *models.IncentiveRun, db *gorm.DB) []*RankedArticle {
+
+	var incentives []models.Incentive
+
+	in := db.Table("incentives").Where("run_id = ?", run.ID)
+	in = in.Where("incentive_type = ?", models.IncentiveFromArticle)
+	in = in.Where("score <> 0")
+	in = in.Order("score desc, id asc")
+	in.Find(&incentives)
+
+	var publications []struct {
+		DNA         string
+		PublishedAt uint64
+	}
+
+	in = db.Table("articles").Joins("JOIN incentives ON incentives.article_dna = articles.dna")
+	in = in.Where("incentives.run_id = ?", run.ID)
+	in = in.Where("incentives.incentive_type = ?", models.IncentiveFromArticle)
+	// Articles published before their block time was kept fall back to the
+	// time they were synchronized
+	in = in.Select("articles.dna" +
+		", CASE WHEN articles.publish_block_time = 0 THEN articles.created_at ELSE articles.publish_block_time END AS published_at")
+	in.Scan(&publications)
+
+	publishedAt := make(map[string]uint64)
+
+	for _, publication := range publications {
+		publishedAt[publication.DNA] = publication.PublishedAt
+	}
+
+	runTime := run.BlockTime
+
+	if runTime == 0 {
+		runTime = uint64(run.CreatedAt)
+	}
+
+	var interactions []models.Incentive
+
+	in = db.Table("incentives").Where("run_id = ?", run.ID)
+	in = in.Where("incentive_type in (?)", []int{models.IncentiveFromLike, models.IncentiveFromComment, models.IncentiveFromShare})
+	in = in.Select("article_dna, incentive_type, score")
+	in.Find(&interactions)
+
+	weights := models.GetScoreWeights()
+	contributions := make(map[string][]*big.Int)
+
+	for _, interaction := range interactions {
+		contribution := new(big.Int).Mul(interaction.Score.Coefficient(), big.NewInt(weights.ForType(interaction.IncentiveType)))
+		contributions[interaction.ArticleDNA] = append(contributions[interaction.ArticleDNA], contribution)
+	}
+
+	var articles []*RankedArticle
+
+	currentRank := 0
+	currentRankScore := big.NewInt(0)
+
+	for i := range incentives {
+
+		score := incentives[i].Score.Coefficient()
+
+		if currentRankScore.Sign() == 0 || score.Cmp(currentRankScore) < 0 {
+			currentRank = currentRank + 1
+			currentRankScore = score
+		}
+
+		article := &RankedArticle{}
+		article.Incentive = &incentives[i]
+		article.Score = score
+		article.Rank = currentRank
+		article.Contributions = contributions[incentives[i].ArticleDNA]
+
+		if published, ok := publishedAt[incentives[i].ArticleDNA]; ok && published < runTime {
+			article.Age = time.Duration(runTime - published) * time.Second
+		}
+
+		articles = append(articles, article)
+	}
+
+	return articles
+}
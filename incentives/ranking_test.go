@@ -0,0 +1,65 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package incentives_test
+
+import (
+	"testing"
+	"math/big"
+	"time"
+	"github.com/magiconair/properties/assert"
+	"github.com/primasio/primas-node/incentives"
+)
+
+func TestRankingStrategies (t *testing.T) {
+
+	article := &incentives.RankedArticle{
+		Score: big.NewInt(1000),
+		Rank: 4,
+		Age: 48 * time.Hour,
+		Contributions: []*big.Int{ big.NewInt(100), big.NewInt(400) } }
+
+	policy := &incentives.Policy{ Ranking: incentives.RankingSqrt }
+
+	strategy, err := incentives.NewRankingStrategy(policy)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, strategy.Score(article).String(), "500")
+
+	policy = &incentives.Policy{ Ranking: incentives.RankingZipf, ZipfExponent: 1 }
+
+	strategy, err = incentives.NewRankingStrategy(policy)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, strategy.Score(article).String(), "250")
+
+	policy = &incentives.Policy{ Ranking: incentives.RankingTimeDecay, HalfLifeHours: 24 }
+
+	strategy, err = incentives.NewRankingStrategy(policy)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, strategy.Score(article).String(), "250")
+
+	policy = &incentives.Policy{ Ranking: incentives.RankingQuadratic }
+
+	strategy, err = incentives.NewRankingStrategy(policy)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, strategy.Score(article).String(), "900")
+
+	// The score of the article is not modified
+
+	assert.Equal(t, article.Score.String(), "1000")
+
+	_, err = incentives.NewRankingStrategy(&incentives.Policy{ Ranking: "unknown" })
+	assert.Equal(t, err != nil, true)
+}
@@ -0,0 +1,104 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package incentives
+
+import (
+	"encoding/csv"
+	"io"
+	"math/big"
+	"time"
+	"github.com/jinzhu/gorm"
+	"github.com/primasio/primas-node/models"
+	"github.com/shopspring/decimal"
+)
+
+type UserPayout struct {
+	UserAddress string
+	Amount      decimal.Decimal `gorm:"type:decimal(65)"`
+}
+
+type ArticlePayout struct {
+	ArticleDNA string
+	Amount     decimal.Decimal `gorm:"type:decimal(65)"`
+}
+
+// Simulation holds the projected payouts of a distribution. Article
+// payouts include the share of the article contributors.
+type Simulation struct {
+	PolicyVersion   string
+	TotalIncentives string
+	Users           []UserPayout
+	Articles        []ArticlePayout
+}
+
+// Simulate runs the distribution against the current pending records in
+// a transaction that is rolled back, so nothing is changed.
+func Simulate(totalIncentives *big.Int, policy *Policy, db *gorm.DB) (*Simulation, error) {
+
+	tx := db.Begin()
+
+	defer tx.Rollback()
+
+	run, err := DistributeIncentivesWithPolicy(totalIncentives, "", 0, uint64(time.Now().Unix()), policy, tx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	simulation := &Simulation{}
+	simulation.PolicyVersion = policy.Version
+	simulation.TotalIncentives = totalIncentives.String()
+
+	in := tx.Table("incentives").Where("run_id = ?", run.ID)
+	in = in.Select("user_address, SUM(amount) AS amount")
+	in = in.Group("user_address").Order("amount desc")
+
+	if err := in.Scan(&simulation.Users).Error; err != nil {
+		return nil, err
+	}
+
+	in = tx.Table("incentives").Where("run_id = ?", run.ID)
+	in = in.Where("incentive_type in (?)", []int{models.IncentiveFromArticle, models.IncentiveFromLike, models.IncentiveFromComment, models.IncentiveFromShare})
+	in = in.Select("article_dna, SUM(amount) AS amount")
+	in = in.Group("article_dna").Order("amount desc")
+
+	if err := in.Scan(&simulation.Articles).Error; err != nil {
+		return nil, err
+	}
+
+	return simulation, nil
+}
+
+// WriteCSV writes one line per user and per article payout.
+func (simulation *Simulation) WriteCSV(w io.Writer) error {
+
+	writer := csv.NewWriter(w)
+
+	writer.Write([]string{"type", "id", "amount"})
+
+	for _, payout := range simulation.Users {
+		writer.Write([]string{"user", payout.UserAddress, payout.Amount.String()})
+	}
+
+	for _, payout := range simulation.Articles {
+		writer.Write([]string{"article", payout.ArticleDNA, payout.Amount.String()})
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
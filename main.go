@@ -28,6 +28,8 @@ import (
 	"github.com/primasio/primas-node/models"
 	"log"
 	"github.com/primasio/primas-node/contracts"
+	"github.com/primasio/primas-node/cron"
+	"github.com/primasio/primas-node/incentives"
 )
 
 func main() {
@@ -36,7 +38,9 @@ func main() {
 
 	environment := flag.String("e", "development", "")
 	flag.Usage = func() {
-		fmt.Println("Usage: primas -e {mode}")
+		fmt.Println("Usage: primas -e {mode} [command]")
+		fmt.Println("Commands:")
+		fmt.Println("  incentives simulate -amount {wei} [-format json|csv] [-ranking {strategy}]")
 		os.Exit(1)
 	}
 
@@ -45,6 +49,17 @@ func main() {
 	// Init Config
 	config.Init(*environment, nil)
 
+	// Check Configuration
+	if err := models.GetHPParams().Validate(); err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
+
+	if _, err := incentives.LoadPolicy(); err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
+
 	// Init Database
 	if err := db.Init(); err != nil {
 		log.Println(err)
@@ -54,6 +69,11 @@ func main() {
 	// Update Database Models
 	models.MigrateAll()
 
+	// Run Command
+	if args := flag.Args(); len(args) != 0 {
+		os.Exit(runCommand(args))
+	}
+
 	// Init Contracts
 	if err := contracts.InitContracts(); err != nil {
 		log.Println(err)
@@ -77,8 +97,15 @@ func main() {
 
 	}()
 
-	// Start Cron Jobs
-	// cron.StartCronJobs()
+	// Start Scheduled Jobs
+	go func () {
+		err := cron.StartScheduler()
+
+		if err != nil {
+			log.Println(err)
+			os.Exit(1)
+		}
+	}()
 
 	// Start HTTP API Server
 	server.Init()
@@ -0,0 +1,89 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"time"
+	"github.com/jinzhu/gorm"
+	"github.com/shopspring/decimal"
+)
+
+// Allowance is the amount a spender was last approved to transfer from the
+// tokens of an owner. transferFrom spends the allowance on chain without
+// an Approval event, so the value is the approved one, not what is left.
+type Allowance struct {
+	ID             uint `gorm:"primary_key"`
+	CreatedAt      uint
+	UpdatedAt      uint
+	OwnerAddress   string `gorm:"size:255;index"`
+	SpenderAddress string `gorm:"size:255;index"`
+	Value          decimal.Decimal `gorm:"type:decimal(65)"`
+	BlockNumber    uint64
+	LogIndex       uint
+	TxHash         string `gorm:"size:255"`
+}
+
+// SetAllowance records an Approval. Events older than the recorded one are
+// ignored so that a block synchronized again does not undo later
+// approvals.
+func SetAllowance(owner, spender string, value decimal.Decimal, blockNumber uint64, logIndex uint, txHash string, db *gorm.DB) {
+
+	allowance := &Allowance{}
+
+	db.Where(&Allowance{ OwnerAddress: owner, SpenderAddress: spender }).First(allowance)
+
+	if allowance.ID != 0 {
+		if allowance.BlockNumber > blockNumber ||
+			(allowance.BlockNumber == blockNumber && allowance.LogIndex > logIndex) {
+			return
+		}
+	} else {
+		allowance.CreatedAt = uint(time.Now().Unix())
+		allowance.OwnerAddress = owner
+		allowance.SpenderAddress = spender
+	}
+
+	allowance.UpdatedAt = uint(time.Now().Unix())
+	allowance.Value = value
+	allowance.BlockNumber = blockNumber
+	allowance.LogIndex = logIndex
+	allowance.TxHash = txHash
+
+	db.Save(allowance)
+}
+
+// GetAllowances lists the allowances of an owner, of a spender, or between
+// both when both are given.
+func GetAllowances(owner, spender string, offset int, db *gorm.DB) []Allowance {
+
+	var allowances []Allowance
+
+	in := db.Model(&Allowance{})
+
+	if owner != "" {
+		in = in.Where("owner_address = ?", owner)
+	}
+
+	if spender != "" {
+		in = in.Where("spender_address = ?", spender)
+	}
+
+	in = in.Order("updated_at desc").Offset(offset).Limit(20)
+	in.Find(&allowances)
+
+	return allowances
+}
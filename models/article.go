@@ -27,6 +27,9 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+// Status of an article removed by the node operator
+const ArticleStatusDeleted = "deleted"
+
 type ArticleContent struct {
 	ID             uint `gorm:"primary_key"`
 	CreatedAt      uint
@@ -54,6 +57,10 @@ type Article struct {
 	ShareCount      uint   `gorm:"default:0"`
 	TotalIncentives decimal.Decimal `gorm:"type:decimal(65);default:0"`
 
+	// Block of the publication and its timestamp, set once synchronized
+	PublishBlockNumber uint64 `binding:"-"`
+	PublishBlockTime   uint64 `binding:"-"`
+
 	// Relations
 	Author            User   `gorm:"ForeignKey:UserAddress;AssociationForeignKey:Address" binding:"-"`
 
@@ -0,0 +1,93 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+// BalanceDrift records a user whose recorded balance differs from the
+// on-chain balance at the synchronized block. When auto correction is
+// enabled the recorded balance is replaced by the on-chain one, and the
+// row keeps the previous value as an audit trail.
+type BalanceDrift struct {
+	ID                 uint `gorm:"primary_key"`
+	CreatedAt          uint `gorm:"index"`
+	UserAddress        string `gorm:"size:255;index"`
+	BlockNumber        string `gorm:"size:64"`
+	RecordedBalance    decimal.Decimal `gorm:"type:decimal(65)"`
+	OnChainBalance     decimal.Decimal `gorm:"type:decimal(65)"`
+	Drift              decimal.Decimal `gorm:"type:decimal(65)"`
+	Corrected          int `gorm:"type:tinyint;default:0"`
+	CorrectedAt        uint
+}
+
+func NewBalanceDrift(check *BalanceCheck) *BalanceDrift {
+
+	drift := &BalanceDrift{}
+
+	drift.CreatedAt = uint(time.Now().Unix())
+	drift.UserAddress = check.UserAddress
+	drift.BlockNumber = check.BlockNumber
+	drift.RecordedBalance = check.RecordedBalance
+	drift.OnChainBalance = check.OnChainBalance
+	drift.Drift = check.Drift
+	drift.Corrected = 0
+
+	return drift
+}
+
+// CorrectBalance replaces the recorded balance of the user with the on-chain
+// balance of the drift. The correction only happens if the node is still
+// synchronized to the block the drift was detected at, otherwise the
+// recorded balance may already include newer transfers.
+func (drift *BalanceDrift) CorrectBalance(db *gorm.DB) bool {
+
+	tx := db.Begin()
+
+	state := &System{}
+	tx.Set("gorm:query_option", "FOR UPDATE").Where(&System{Key: "CurrentBlockNumber"}).First(state)
+
+	if state.Value != drift.BlockNumber {
+		tx.Rollback()
+		return false
+	}
+
+	user := &User{}
+	tx.Set("gorm:query_option", "FOR UPDATE").Where(&User{Address: drift.UserAddress}).First(user)
+
+	if user.ID == 0 {
+		tx.Rollback()
+		return false
+	}
+
+	drift.RecordedBalance = user.Balance
+	drift.Drift = user.Balance.Sub(drift.OnChainBalance)
+	drift.Corrected = 1
+	drift.CorrectedAt = uint(time.Now().Unix())
+
+	user.Balance = drift.OnChainBalance
+
+	tx.Set("gorm:save_associations", false).Save(user)
+	tx.Save(drift)
+
+	tx.Commit()
+
+	return true
+}
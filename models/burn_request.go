@@ -0,0 +1,154 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"errors"
+	"strconv"
+	"time"
+	"github.com/jinzhu/gorm"
+	"github.com/primasio/primas-node/config"
+	"github.com/shopspring/decimal"
+)
+
+const BurnRequestPending = 1
+const BurnRequestConfirmed = 2
+const BurnRequestFailed = 3
+
+// Default burn settings
+const BurnMaxAgeSeconds = 600
+const BurnMaxSkewSeconds = 60
+
+// BurnRequest is a token burn submitted by a user. The signature of the
+// timestamp can only be used once, UniqueSignature holds it for the
+// requests submitted by this node and is null for the burns seen on chain.
+type BurnRequest struct {
+	ID              uint `gorm:"primary_key"`
+	CreatedAt       uint
+	UpdatedAt       uint
+	UserAddress     string `gorm:"size:255;index"`
+	Timestamp       int64
+	Signature       string `gorm:"size:255;index"`
+	UniqueSignature *string `gorm:"size:255;unique_index"`
+	TxHash          string `gorm:"size:255;index"`
+	BlockNumber     uint64
+	Amount          decimal.Decimal `gorm:"type:decimal(65)"`
+	BalanceAdjusted bool
+	Status          uint `gorm:"index"`
+	LastError       string `gorm:"type:text"`
+}
+
+// GetBurnMaxAge returns how old in seconds a signed timestamp can be.
+func GetBurnMaxAge() int64 {
+
+	if c := config.GetConfig(); c != nil && c.IsSet("burn.max_age_seconds") {
+		return c.GetInt64("burn.max_age_seconds")
+	}
+
+	return BurnMaxAgeSeconds
+}
+
+// ValidateBurnTimestamp checks that the signed timestamp is recent and
+// that it is later than the previous burn request of the user.
+func ValidateBurnTimestamp(userAddress string, timestamp string, db *gorm.DB) (int64, error) {
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+
+	if err != nil {
+		return 0, errors.New("invalid timestamp")
+	}
+
+	maxAge := GetBurnMaxAge()
+	maxSkew := int64(BurnMaxSkewSeconds)
+
+	if c := config.GetConfig(); c != nil && c.IsSet("burn.max_skew_seconds") {
+		maxSkew = c.GetInt64("burn.max_skew_seconds")
+	}
+
+	now := time.Now().Unix()
+
+	if ts < now - maxAge {
+		return 0, errors.New("timestamp expired")
+	}
+
+	if ts > now + maxSkew {
+		return 0, errors.New("timestamp in the future")
+	}
+
+	last := &BurnRequest{}
+
+	db.Where(&BurnRequest{ UserAddress: userAddress }).Order("timestamp desc").First(last)
+
+	if last.ID != 0 && ts <= last.Timestamp {
+		return 0, errors.New("timestamp must be later than the previous burn request")
+	}
+
+	return ts, nil
+}
+
+// CreateBurnRequest records a pending burn request before its transaction
+// is submitted. The user row is locked so that the timestamps of the user
+// are checked in order, the unique index on the signature refuses a
+// signature already used even by a concurrent request.
+func CreateBurnRequest(userAddress string, timestamp string, signature string, db *gorm.DB) (*BurnRequest, error) {
+
+	tx := db.Begin()
+
+	user := &User{}
+
+	tx.Set("gorm:query_option", "FOR UPDATE").Where(&User{ Address: userAddress }).First(user)
+
+	ts, err := ValidateBurnTimestamp(userAddress, timestamp, tx)
+
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	request := &BurnRequest{}
+	request.CreatedAt = uint(time.Now().Unix())
+	request.UpdatedAt = request.CreatedAt
+	request.UserAddress = userAddress
+	request.Timestamp = ts
+	request.Signature = signature
+	request.UniqueSignature = &signature
+	request.Amount = decimal.Zero
+	request.Status = BurnRequestPending
+
+	if err := tx.Create(request).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.New("signature already used")
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// GetBurnRequests lists the burn requests of a user, latest first.
+func GetBurnRequests(userAddress string, offset int, db *gorm.DB) []BurnRequest {
+
+	var requests []BurnRequest
+
+	in := db.Where(&BurnRequest{ UserAddress: userAddress })
+	in = in.Order("id desc").Offset(offset).Limit(20)
+	in.Find(&requests)
+
+	return requests
+}
@@ -0,0 +1,60 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import "github.com/jinzhu/gorm"
+
+// ContractAdminChange records an ownership transfer of a contract.
+type ContractAdminChange struct {
+	ID              uint `gorm:"primary_key"`
+	CreatedAt       uint
+	ContractName    string `gorm:"size:255;index"`
+	ContractAddress string `gorm:"size:255;index"`
+	PreviousOwner   string `gorm:"size:255"`
+	NewOwner        string `gorm:"size:255;index"`
+	BlockNumber     uint64
+	LogIndex        uint
+	TxHash          string `gorm:"size:255"`
+}
+
+// RecordContractAdminChange saves the change unless the event was already
+// recorded.
+func RecordContractAdminChange(change *ContractAdminChange, db *gorm.DB) {
+
+	existing := &ContractAdminChange{}
+
+	db.Where("tx_hash = ? AND log_index = ?", change.TxHash, change.LogIndex).First(existing)
+
+	if existing.ID != 0 {
+		return
+	}
+
+	db.Save(change)
+}
+
+// GetContractAdminHistory lists the ownership transfers of a contract,
+// latest first.
+func GetContractAdminHistory(contractName string, offset int, db *gorm.DB) []ContractAdminChange {
+
+	var changes []ContractAdminChange
+
+	in := db.Where(&ContractAdminChange{ ContractName: contractName })
+	in = in.Order("block_number desc, log_index desc").Offset(offset).Limit(20)
+	in.Find(&changes)
+
+	return changes
+}
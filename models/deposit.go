@@ -0,0 +1,137 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"errors"
+	"math/big"
+	"time"
+	"github.com/jinzhu/gorm"
+	"github.com/primasio/primas-node/config"
+	"github.com/shopspring/decimal"
+)
+
+// Deposit modes
+const DepositModeStatus = "status"
+const DepositModeTime = "time"
+
+// DepositPolicy is the amount of tokens locked when a resource is
+// created. Status deposits stay locked until the resource is released,
+// time deposits expire after LockHours.
+type DepositPolicy struct {
+	ResourceType uint
+	Amount       *big.Int
+	Mode         string
+	LockHours    int64
+}
+
+// GetDepositPolicy reads the deposit policy of a resource type from
+// deposit.group or deposit.article. A zero amount disables the deposit.
+func GetDepositPolicy(resourceType uint) (*DepositPolicy, error) {
+
+	policy := &DepositPolicy{ ResourceType: resourceType, Amount: big.NewInt(0), Mode: DepositModeStatus }
+
+	c := config.GetConfig()
+
+	key := "deposit." + TokenLockResourceName(resourceType)
+
+	if c == nil || !c.IsSet(key) {
+		return policy, nil
+	}
+
+	if amount := c.GetString(key + ".amount"); amount != "" {
+
+		value, ok := new(big.Int).SetString(amount, 10)
+
+		if !ok || value.Sign() < 0 {
+			return nil, errors.New("invalid deposit amount " + amount)
+		}
+
+		policy.Amount = value
+	}
+
+	if mode := c.GetString(key + ".mode"); mode != "" {
+		policy.Mode = mode
+	}
+
+	policy.LockHours = c.GetInt64(key + ".lock_hours")
+
+	switch policy.Mode {
+	case DepositModeStatus:
+	case DepositModeTime:
+		if policy.LockHours <= 0 {
+			return nil, errors.New("time deposit requires lock_hours")
+		}
+	default:
+		return nil, errors.New("unknown deposit mode " + policy.Mode)
+	}
+
+	return policy, nil
+}
+
+func (policy *DepositPolicy) Enabled() bool {
+	return policy.Amount.Sign() > 0
+}
+
+// Check makes sure the user can afford the deposit. The row of the user
+// is locked in db, the transaction of the request recording the deposit,
+// so that concurrent requests of the user see the deposits of each other.
+func (policy *DepositPolicy) Check(user *User, db *gorm.DB) error {
+
+	if !policy.Enabled() {
+		return nil
+	}
+
+	db.Set("gorm:query_option", "FOR UPDATE").Where(&User{ Address: user.Address }).First(&User{})
+
+	if user.GetSpendableBalance(db).Cmp(policy.Amount) < 0 {
+		return errors.New("insufficient balance for a deposit of " + policy.Amount.String())
+	}
+
+	return nil
+}
+
+// NewLock returns the lock of the deposit for the resource.
+func (policy *DepositPolicy) NewLock(userAddress string, dna string) *TokenLock {
+
+	lock := &TokenLock{}
+	lock.CreatedAt = uint(time.Now().Unix())
+	lock.UserAddress = userAddress
+	lock.ResourceType = policy.ResourceType
+	lock.ResourceDNA = dna
+	lock.Amount = decimal.NewFromBigInt(policy.Amount, 0)
+	lock.TxStatus = TxStatusPending
+
+	if policy.Mode == DepositModeTime {
+		lock.Expire = lock.CreatedAt + uint(policy.LockHours * 3600)
+	}
+
+	return lock
+}
+
+// GetDepositLock returns the lock of the deposit of a resource that is not
+// released yet.
+func GetDepositLock(resourceType uint, dna string, db *gorm.DB) *TokenLock {
+
+	lock := &TokenLock{}
+
+	in := db.Where("resource_type = ? AND resource_dna = ?", resourceType, dna)
+	in = in.Where("unlock_status NOT IN (?)", []uint{TokenLockUnlockSubmitted, TokenLockUnlockMined})
+	in.First(lock)
+
+	return lock
+}
@@ -23,6 +23,9 @@ import (
 	"errors"
 )
 
+// Status of a group removed by the node operator
+const GroupStatusDeleted = "deleted"
+
 type Group struct {
 	ID              uint `gorm:"primary_key"`
 	CreatedAt       uint
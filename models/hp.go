@@ -0,0 +1,210 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"errors"
+	"math/big"
+	"time"
+	"github.com/jinzhu/gorm"
+	"github.com/primasio/primas-node/config"
+	"github.com/shopspring/decimal"
+)
+
+// Default HP parameters
+const HPTheta = 5
+const HPWindowHours = 12
+const HPE = 3 // E = 2.7 = 3
+const HPDecimals = 18
+
+// HPParams are the parameters of the HP formula:
+//
+//   HP = balance / (theta + count / (e^(theta - count) + 1))^2 / 10^decimals
+//
+// where count is the number of operations of the user within the window.
+// The more a user operates within the window, the lower the HP. Operations
+// are counted by the block time of their event so that every node
+// calculates the same HP for an interaction.
+type HPParams struct {
+	Theta       int64
+	WindowHours int64
+	E           int64
+	Decimals    int64
+}
+
+// HPExplanation holds the inputs and intermediate terms of an HP
+// calculation. The integer division of the formula is kept.
+type HPExplanation struct {
+	UserAddress    string
+	Params         HPParams
+	Balance        decimal.Decimal
+	OperationCount int
+	WindowStart    uint64
+	WindowEnd      uint64
+	Exponential    string // e^(theta - count), 1 once count reaches theta
+	CountTerm      string // count / (exponential + 1)
+	Denominator    string // (theta + count term)^2
+	HP             string
+}
+
+// HPHistory is the daily snapshot of the HP of a user.
+type HPHistory struct {
+	ID             uint `gorm:"primary_key"`
+	CreatedAt      uint
+	Date           string `gorm:"size:10;index"`
+	UserAddress    string `gorm:"size:255;index"`
+	Balance        decimal.Decimal `gorm:"type:decimal(65)"`
+	OperationCount int
+	HP             decimal.Decimal `gorm:"type:decimal(65)"`
+	Theta          int64
+	WindowHours    int64
+}
+
+// GetHPParams returns the HP parameters configured in hp, falling back to
+// the defaults.
+func GetHPParams() HPParams {
+
+	params := HPParams{ Theta: HPTheta, WindowHours: HPWindowHours, E: HPE, Decimals: HPDecimals }
+
+	c := config.GetConfig()
+
+	if c == nil {
+		return params
+	}
+
+	if c.IsSet("hp.theta") {
+		params.Theta = c.GetInt64("hp.theta")
+	}
+
+	if c.IsSet("hp.window_hours") {
+		params.WindowHours = c.GetInt64("hp.window_hours")
+	}
+
+	if c.IsSet("hp.e") {
+		params.E = c.GetInt64("hp.e")
+	}
+
+	if c.IsSet("hp.decimals") {
+		params.Decimals = c.GetInt64("hp.decimals")
+	}
+
+	return params
+}
+
+// Validate checks that the formula is defined for the parameters: theta
+// of at least 1 so that the denominator is never zero, e of at least 2 so
+// that operations lower the HP, decimals not negative and a window of at
+// least one hour.
+func (params HPParams) Validate() error {
+
+	if params.Theta < 1 {
+		return errors.New("hp.theta must be at least 1")
+	}
+
+	if params.E < 2 {
+		return errors.New("hp.e must be at least 2")
+	}
+
+	if params.Decimals < 0 {
+		return errors.New("hp.decimals cannot be negative")
+	}
+
+	if params.WindowHours < 1 {
+		return errors.New("hp.window_hours must be at least 1")
+	}
+
+	return nil
+}
+
+// GetHP returns the HP of the user at the given time.
+func (user *User) GetHP(at uint64, db *gorm.DB) *big.Int {
+	hp, _ := new(big.Int).SetString(user.ExplainHP(at, db).HP, 10)
+	return hp
+}
+
+// ExplainHP calculates the HP of the user at the given time, the block time
+// of an interaction or the current time, and returns every term of it.
+func (user *User) ExplainHP(at uint64, db *gorm.DB) *HPExplanation {
+
+	params := GetHPParams()
+
+	window := uint64(params.WindowHours * 3600)
+
+	explanation := &HPExplanation{}
+	explanation.UserAddress = user.Address
+	explanation.Params = params
+	explanation.Balance = user.Balance
+	explanation.WindowEnd = at
+
+	if at > window {
+		explanation.WindowStart = at - window
+	}
+
+	// Operation count
+
+	in := db.Table("incentives").Where(&Incentive{UserAddress: user.Address})
+	in = in.Where("block_time > ? AND block_time <= ?", explanation.WindowStart, explanation.WindowEnd)
+	in.Count(&explanation.OperationCount)
+
+	count := big.NewInt(int64(explanation.OperationCount))
+	theta := big.NewInt(params.Theta)
+
+	exponential := new(big.Int).Exp(big.NewInt(params.E), new(big.Int).Sub(theta, count), nil)
+
+	countTerm := new(big.Int).Div(count, new(big.Int).Add(exponential, big.NewInt(1)))
+
+	denominator := new(big.Int).Add(theta, countTerm)
+	denominator = denominator.Mul(denominator, denominator)
+
+	hp := new(big.Int).Div(user.Balance.Coefficient(), denominator)
+	hp = hp.Div(hp, new(big.Int).Exp(big.NewInt(10), big.NewInt(params.Decimals), nil))
+
+	explanation.Exponential = exponential.String()
+	explanation.CountTerm = countTerm.String()
+	explanation.Denominator = denominator.String()
+	explanation.HP = hp.String()
+
+	return explanation
+}
+
+// SnapshotHP records the HP of the user for the day, once per day, at the
+// time it runs.
+func (user *User) SnapshotHP(date string, db *gorm.DB) {
+
+	history := &HPHistory{}
+
+	db.Where(&HPHistory{ Date: date, UserAddress: user.Address }).First(history)
+
+	if history.ID != 0 {
+		return
+	}
+
+	explanation := user.ExplainHP(uint64(time.Now().Unix()), db)
+
+	hp, _ := decimal.NewFromString(explanation.HP)
+
+	history.CreatedAt = uint(time.Now().Unix())
+	history.Date = date
+	history.UserAddress = user.Address
+	history.Balance = user.Balance
+	history.OperationCount = explanation.OperationCount
+	history.HP = hp
+	history.Theta = explanation.Params.Theta
+	history.WindowHours = explanation.Params.WindowHours
+
+	db.Save(history)
+}
@@ -22,6 +22,7 @@ import (
 	"math/big"
 	"github.com/shopspring/decimal"
 	"log"
+	"github.com/primasio/primas-node/config"
 )
 
 const IncentiveFromArticle = 1
@@ -29,10 +30,21 @@ const IncentiveFromGroup = 2
 const IncentiveFromLike  = 3
 const IncentiveFromComment = 4
 const IncentiveFromShare = 5
+const IncentiveFromNode = 6
+
+// Default weights of interactions in article and group scores
+const LikeScoreWeight = 1
+const CommentScoreWeight = 10
+const ShareScoreWeight = 100
 
 const IncentivesPending = 1
 const IncentivesCalculating = 2
 const IncentivesPaid = 3
+const IncentivesPaying = 4
+
+// Records of a batch abandoned after incentives.max_attempts, they are no
+// longer paid by the node
+const IncentivesAbandoned = 5
 
 type Incentive struct {
 	ID              uint `gorm:"primary_key"`
@@ -44,6 +56,14 @@ type Incentive struct {
 	Amount          decimal.Decimal `gorm:"type:decimal(65)"`
 	Status          uint `gorm:"index"`
 	Score           decimal.Decimal `gorm:"type:decimal(65)"`
+	BatchID         uint `gorm:"index"`
+	RunID           uint `gorm:"index"`
+	RawScore        decimal.Decimal `gorm:"type:decimal(65)"`
+
+	// Block of the event of an interaction and its timestamp, the same on
+	// every node unlike CreatedAt
+	BlockNumber     uint64 `gorm:"index"`
+	BlockTime       uint64 `gorm:"index"`
 
 	// Relations
 	IncentiveArticle Article   `gorm:"ForeignKey:ArticleDNA;AssociationForeignKey:DNA"`
@@ -58,17 +78,30 @@ type GroupIncentive struct {
 	Status          uint `gorm:"index"`
 	AvgScore        decimal.Decimal `gorm:"type:decimal(65)"`
 	AvgCount        uint
+	RunID           uint `gorm:"index"`
+}
+
+type ScoreWeights struct {
+	Like    int64
+	Comment int64
+	Share   int64
 }
 
 type TotalScore struct {
 	Score decimal.Decimal `gorm:"type:decimal(65)"`
 }
 
-func LikeArticleIncentive(like *ArticleLike, db *gorm.DB) {
+type GroupScore struct {
+	GroupDNA string
+	Count    uint
+	Score    decimal.Decimal `gorm:"type:decimal(65)"`
+}
+
+func LikeArticleIncentive(like *ArticleLike, blockNumber uint64, blockTime uint64, db *gorm.DB) {
 
 	u := &User{ Address: like.GroupMemberAddress }
 	db.Where(u).First(&u)
-	hp := u.GetHP(db)
+	hp := u.GetHP(blockTime, db)
 
 	inc := newIncentive()
 	inc.IncentiveType = IncentiveFromLike
@@ -77,10 +110,12 @@ func LikeArticleIncentive(like *ArticleLike, db *gorm.DB) {
 	inc.GroupDNA = like.GroupDNA
 
 	inc.Score = decimal.NewFromBigInt(hp, 0)
+	inc.BlockNumber = blockNumber
+	inc.BlockTime = blockTime
 
 	db.Set("gorm:save_associations", false).Save(inc)
 
-	likeWeight := big.NewInt(1)
+	likeWeight := big.NewInt(GetScoreWeights().Like)
 
 	// Update article score
 	updateArticleScore(like.ArticleDNA, hp.Mul(hp, likeWeight), db)
@@ -88,11 +123,11 @@ func LikeArticleIncentive(like *ArticleLike, db *gorm.DB) {
 	// Update group score
 }
 
-func CommentArticleIncentive(comment *ArticleComment, db *gorm.DB) {
+func CommentArticleIncentive(comment *ArticleComment, blockNumber uint64, blockTime uint64, db *gorm.DB) {
 
 	u := &User{ Address: comment.GroupMemberAddress }
 	db.Where(u).First(&u)
-	hp := u.GetHP(db)
+	hp := u.GetHP(blockTime, db)
 
 	inc := newIncentive()
 	inc.IncentiveType = IncentiveFromComment
@@ -100,16 +135,18 @@ func CommentArticleIncentive(comment *ArticleComment, db *gorm.DB) {
 	inc.Score = decimal.NewFromBigInt(hp, 0)
 	inc.ArticleDNA = comment.ArticleDNA
 	inc.GroupDNA = comment.GroupDNA
+	inc.BlockNumber = blockNumber
+	inc.BlockTime = blockTime
 
 	db.Set("gorm:save_associations", false).Save(inc)
 
-	commentWeight := big.NewInt(10)
+	commentWeight := big.NewInt(GetScoreWeights().Comment)
 
 	// Update article score
 	updateArticleScore(comment.ArticleDNA, hp.Mul(hp, commentWeight), db)
 }
 
-func ShareArticleIncentive(share *GroupArticle, db *gorm.DB) {
+func ShareArticleIncentive(share *GroupArticle, blockNumber uint64, blockTime uint64, db *gorm.DB) {
 
 	u := &User{ Address: share.MemberAddress }
 	db.Where(u).First(&u)
@@ -118,7 +155,7 @@ func ShareArticleIncentive(share *GroupArticle, db *gorm.DB) {
 		log.Println("user does not exist: " + share.MemberAddress)
 	}
 
-	hp := u.GetHP(db)
+	hp := u.GetHP(blockTime, db)
 
 	inc := newIncentive()
 	inc.IncentiveType = IncentiveFromShare
@@ -126,15 +163,59 @@ func ShareArticleIncentive(share *GroupArticle, db *gorm.DB) {
 	inc.Score = decimal.NewFromBigInt(hp, 0)
 	inc.ArticleDNA = share.ArticleDNA
 	inc.GroupDNA = share.GroupDNA
+	inc.BlockNumber = blockNumber
+	inc.BlockTime = blockTime
 
 	db.Set("gorm:save_associations", false).Save(inc)
 
-	shareWeight := big.NewInt(100)
+	shareWeight := big.NewInt(GetScoreWeights().Share)
 
 	// Update article score
 	updateArticleScore(share.ArticleDNA, hp.Mul(hp, shareWeight), db)
 }
 
+// GetScoreWeights returns the interaction weights configured in
+// incentives.weights, falling back to the defaults.
+func GetScoreWeights() ScoreWeights {
+
+	weights := ScoreWeights{ Like: LikeScoreWeight, Comment: CommentScoreWeight, Share: ShareScoreWeight }
+
+	c := config.GetConfig()
+
+	if c == nil {
+		return weights
+	}
+
+	if c.IsSet("incentives.weights.like") {
+		weights.Like = c.GetInt64("incentives.weights.like")
+	}
+
+	if c.IsSet("incentives.weights.comment") {
+		weights.Comment = c.GetInt64("incentives.weights.comment")
+	}
+
+	if c.IsSet("incentives.weights.share") {
+		weights.Share = c.GetInt64("incentives.weights.share")
+	}
+
+	return weights
+}
+
+// ForType returns the weight of an interaction incentive type.
+func (weights ScoreWeights) ForType(incentiveType uint) int64 {
+
+	switch incentiveType {
+	case IncentiveFromLike:
+		return weights.Like
+	case IncentiveFromComment:
+		return weights.Comment
+	case IncentiveFromShare:
+		return weights.Share
+	default:
+		return 0
+	}
+}
+
 func newIncentive() *Incentive {
 	inc := &Incentive{}
 	inc.CreatedAt = uint(time.Now().Unix())
@@ -0,0 +1,110 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"strconv"
+	"strings"
+)
+
+const IncentiveBatchSubmitted = 1
+const IncentiveBatchConfirmed = 2
+const IncentiveBatchFailed = 3
+const IncentiveBatchAbandoned = 4
+
+// IncentiveBatch is one grantIncentives transaction paying a set of
+// incentive records of a run. The records are marked paid once the
+// transaction receipt is successful, failed batches are submitted again
+// until they are abandoned. A transaction not mined in time is replaced by one with the same nonce,
+// TxHashes keeps all of them since any may be mined.
+type IncentiveBatch struct {
+	ID              uint `gorm:"primary_key"`
+	CreatedAt       uint
+	UpdatedAt       uint
+	RunID           uint `gorm:"index"`
+	TxHash          string `gorm:"size:255;index"`
+	TxHashes        string `gorm:"type:text"`
+	Nonce           uint64
+	GasPrice        decimal.Decimal `gorm:"type:decimal(65)"`
+	SubmittedAt     uint
+	IncentiveIDs    string `gorm:"type:text"`
+	RecipientCount  uint
+	TotalAmount     decimal.Decimal `gorm:"type:decimal(65)"`
+	Status          uint `gorm:"index"`
+	Attempts        uint `gorm:"default:0"`
+	LastError       string `gorm:"type:text"`
+}
+
+func (batch *IncentiveBatch) SetIncentiveIDs(ids []uint) {
+
+	var parts []string
+
+	for _, id := range ids {
+		parts = append(parts, strconv.FormatUint(uint64(id), 10))
+	}
+
+	batch.IncentiveIDs = strings.Join(parts, ",")
+}
+
+func (batch *IncentiveBatch) GetIncentiveIDs() []uint {
+
+	var ids []uint
+
+	if batch.IncentiveIDs == "" {
+		return ids
+	}
+
+	for _, part := range strings.Split(batch.IncentiveIDs, ",") {
+		if id, err := strconv.ParseUint(part, 10, 64); err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+
+	return ids
+}
+
+// AddTxHash records a transaction sent for the batch.
+func (batch *IncentiveBatch) AddTxHash(txHash string) {
+
+	batch.TxHash = txHash
+
+	if batch.TxHashes == "" {
+		batch.TxHashes = txHash
+	} else {
+		batch.TxHashes = batch.TxHashes + "," + txHash
+	}
+}
+
+// GetTxHashes returns the transactions sent with the current nonce of the
+// batch, the latest first.
+func (batch *IncentiveBatch) GetTxHashes() []string {
+
+	var hashes []string
+
+	if batch.TxHashes == "" {
+		return hashes
+	}
+
+	parts := strings.Split(batch.TxHashes, ",")
+
+	for i := len(parts) - 1; i >= 0; i-- {
+		hashes = append(hashes, parts[i])
+	}
+
+	return hashes
+}
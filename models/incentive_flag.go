@@ -0,0 +1,45 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+// Interaction on the author's own article
+const IncentiveFlagSelfInteraction = 1
+
+// Interaction between accounts interacting with each other's articles
+const IncentiveFlagRing = 2
+
+// Interaction beyond the allowed count within the burst window
+const IncentiveFlagBurst = 3
+
+// IncentiveFlag records an interaction whose score was discounted before
+// distribution, for later review.
+type IncentiveFlag struct {
+	ID              uint `gorm:"primary_key"`
+	CreatedAt       uint
+	RunID           uint `gorm:"index"`
+	IncentiveID     uint `gorm:"index"`
+	FlagType        uint `gorm:"index"`
+	UserAddress     string `gorm:"size:255;index"`
+	ArticleDNA      string `gorm:"size:255;index"`
+	OriginalScore   decimal.Decimal `gorm:"type:decimal(65)"`
+	DiscountedScore decimal.Decimal `gorm:"type:decimal(65)"`
+	Details         string `gorm:"type:text"`
+}
@@ -0,0 +1,239 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"time"
+	"github.com/jinzhu/gorm"
+	"github.com/shopspring/decimal"
+)
+
+const IncentiveRunCalculating = 1
+const IncentiveRunCalculated = 2
+const IncentiveRunReverted = 3
+
+// Policy version of the run holding the records of nodes older than runs
+const LegacyPolicyVersion = "legacy"
+
+// IncentiveRun is one distribution of an inflation. A run still
+// calculating after a crash is reverted and calculated again, only
+// incentives of calculated runs are paid.
+type IncentiveRun struct {
+	ID                uint `gorm:"primary_key"`
+	CreatedAt         uint
+	UpdatedAt         uint
+	FinishedAt        uint
+	TxHash            string `gorm:"size:255;index"`
+	BlockNumber       uint64
+	BlockTime         uint64
+	PolicyVersion     string
+	Ranking           string
+	TotalIncentives   decimal.Decimal `gorm:"type:decimal(65)"`
+	ArticleAmount     decimal.Decimal `gorm:"type:decimal(65)"`
+	GroupAmount       decimal.Decimal `gorm:"type:decimal(65)"`
+	NodeAmount        decimal.Decimal `gorm:"type:decimal(65)"`
+	DistributedAmount decimal.Decimal `gorm:"type:decimal(65)"`
+	IncentiveCount    uint
+	Status            uint `gorm:"index"`
+}
+
+type IncentiveRunBreakdown struct {
+	IncentiveType uint
+	Count         uint
+	Amount        decimal.Decimal `gorm:"type:decimal(65)"`
+}
+
+type ArticlePayoutTotal struct {
+	ArticleDNA string
+	Amount     decimal.Decimal `gorm:"type:decimal(65)"`
+}
+
+type IncentiveRunReport struct {
+	Run       IncentiveRun
+	Breakdown []IncentiveRunBreakdown
+	PaidCount uint
+}
+
+// Finish records the totals of the run and marks it calculated.
+func (run *IncentiveRun) Finish(db *gorm.DB) {
+
+	total := &TotalScore{}
+
+	in := db.Table("incentives").Where("run_id = ?", run.ID)
+	in = in.Select("SUM(amount) AS score")
+	in.Scan(total)
+
+	var count uint
+
+	db.Table("incentives").Where("run_id = ?", run.ID).Count(&count)
+
+	run.DistributedAmount = total.Score
+	run.IncentiveCount = count
+	run.Status = IncentiveRunCalculated
+	run.FinishedAt = uint(time.Now().Unix())
+	run.UpdatedAt = run.FinishedAt
+
+	db.Save(run)
+}
+
+// Revert puts the records of an unpaid run back to pending so that they
+// can be distributed again. The caller locks the run row, which
+// AssignIncentives also locks before it pays records of the run.
+func (run *IncentiveRun) Revert(db *gorm.DB) error {
+
+	var paying uint
+
+	in := db.Table("incentives").Where("run_id = ?", run.ID)
+	in = in.Where("status in (?)", []int{IncentivesPaying, IncentivesPaid, IncentivesAbandoned})
+	in.Count(&paying)
+
+	if paying != 0 {
+		return errors.New("incentive run is already being paid")
+	}
+
+	// Undo article totals
+
+	var articleTotals []ArticlePayoutTotal
+
+	in = db.Table("incentives").Where("run_id = ?", run.ID)
+	in = in.Where("incentive_type = ?", IncentiveFromArticle)
+	in = in.Select("article_dna, SUM(amount) AS amount").Group("article_dna")
+	in.Scan(&articleTotals)
+
+	for _, articleTotal := range articleTotals {
+
+		article := &Article{ DNA: articleTotal.ArticleDNA }
+
+		db.Set("gorm:query_option", "FOR UPDATE").Where(article).First(article)
+
+		if article.ID == 0 {
+			continue
+		}
+
+		article.TotalIncentives = article.TotalIncentives.Sub(articleTotal.Amount)
+		db.Set("gorm:save_associations", false).Save(article)
+	}
+
+	// Records created by the run
+
+	in = db.Where("run_id = ?", run.ID)
+	in = in.Where("incentive_type in (?)", []int{IncentiveFromGroup, IncentiveFromNode})
+	in.Delete(Incentive{})
+
+	db.Where("run_id = ?", run.ID).Delete(GroupIncentive{})
+	db.Where("run_id = ?", run.ID).Delete(IncentiveFlag{})
+
+	// Records locked by the run
+
+	in = db.Table("incentives").Where("run_id = ?", run.ID)
+	in.Updates(map[string]interface{}{
+		"status": IncentivesPending,
+		"amount": 0,
+		"score": gorm.Expr("raw_score"),
+		"run_id": 0 })
+
+	in = db.Table("node_activities").Where("run_id = ?", run.ID)
+	in.Updates(map[string]interface{}{"status": IncentivesPending, "run_id": 0})
+
+	run.Status = IncentiveRunReverted
+	run.UpdatedAt = uint(time.Now().Unix())
+
+	db.Save(run)
+
+	return nil
+}
+
+// AdoptLegacyIncentives moves the records left calculating without a run
+// by nodes older than runs into a run of their own. Those nodes never
+// granted incentives on chain, so the run is reverted right away: its
+// interactions go back to pending and are paid by the next run, the
+// amounts the old node calculated are dropped. The run keeps the totals
+// it held.
+func AdoptLegacyIncentives(db *gorm.DB) {
+
+	var count uint
+
+	db.Table("incentives").Where("status = ? AND run_id = 0", IncentivesCalculating).Count(&count)
+
+	if count == 0 {
+		return
+	}
+
+	blockNumber, _ := strconv.ParseUint(GetState("CurrentBlockNumber", db), 10, 64)
+
+	tx := db.Begin()
+
+	run := &IncentiveRun{}
+	run.CreatedAt = uint(time.Now().Unix())
+	run.UpdatedAt = run.CreatedAt
+	run.BlockNumber = blockNumber
+	run.PolicyVersion = LegacyPolicyVersion
+	run.TotalIncentives = decimal.Zero
+	run.ArticleAmount = decimal.Zero
+	run.GroupAmount = decimal.Zero
+	run.NodeAmount = decimal.Zero
+	run.DistributedAmount = decimal.Zero
+	run.Status = IncentiveRunCalculating
+
+	tx.Save(run)
+
+	// The score is kept so that the run can be reverted
+
+	in := tx.Table("incentives").Where("status = ? AND run_id = 0", IncentivesCalculating)
+	in.Updates(map[string]interface{}{"run_id": run.ID, "raw_score": gorm.Expr("score")})
+
+	in = tx.Table("group_incentives").Where("status = ? AND run_id = 0", IncentivesCalculating)
+	in.Updates(map[string]interface{}{"run_id": run.ID})
+
+	in = tx.Table("node_activities").Where("status = ? AND run_id = 0", IncentivesCalculating)
+	in.Updates(map[string]interface{}{"run_id": run.ID})
+
+	run.Finish(tx)
+
+	run.TotalIncentives = run.DistributedAmount
+	tx.Save(run)
+
+	if err := run.Revert(tx); err != nil {
+		tx.Rollback()
+		log.Println("incentive run #" + strconv.FormatUint(uint64(run.ID), 10) + " of legacy records not reverted: " + err.Error())
+		return
+	}
+
+	tx.Commit()
+
+	log.Println("incentive run #" + strconv.FormatUint(uint64(run.ID), 10) + " reverted " + strconv.FormatUint(uint64(count), 10) + " records left calculating")
+}
+
+// GetReport returns the run with its amounts per incentive type.
+func (run *IncentiveRun) GetReport(db *gorm.DB) *IncentiveRunReport {
+
+	report := &IncentiveRunReport{ Run: *run }
+
+	in := db.Table("incentives").Where("run_id = ?", run.ID)
+	in = in.Select("incentive_type, COUNT(*) AS count, SUM(amount) AS amount")
+	in = in.Group("incentive_type").Order("incentive_type asc")
+	in.Scan(&report.Breakdown)
+
+	in = db.Table("incentives").Where("run_id = ?", run.ID)
+	in = in.Where("status = ?", IncentivesPaid)
+	in.Count(&report.PaidCount)
+
+	return report
+}
@@ -0,0 +1,222 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"github.com/jinzhu/gorm"
+	"github.com/shopspring/decimal"
+)
+
+const StatementDateFormat = "2006-01-02"
+
+// IncentiveStatementEntry sums the incentives of one type paid on a day.
+type IncentiveStatementEntry struct {
+	Date          string
+	IncentiveType uint
+	Type          string
+	Count         uint
+	Amount        decimal.Decimal
+	ArticleDNAs   []string
+	GroupDNAs     []string
+	TxHashes      []string
+}
+
+type IncentiveStatementTotal struct {
+	IncentiveType uint
+	Type          string
+	Count         uint
+	Amount        decimal.Decimal
+}
+
+// IncentiveStatement lists the incentives paid to a user between From and
+// To, both days included.
+type IncentiveStatement struct {
+	UserAddress string
+	From        string
+	To          string
+	Entries     []*IncentiveStatementEntry
+	Totals      []*IncentiveStatementTotal
+	Total       decimal.Decimal
+}
+
+type statementIncentive struct {
+	IncentiveType uint
+	ArticleDNA    string
+	GroupDNA      string
+	Amount        decimal.Decimal `gorm:"type:decimal(65)"`
+	PaidAt        uint
+	TxHash        string
+}
+
+// IncentiveTypeName returns a readable name of an incentive type.
+func IncentiveTypeName(incentiveType uint) string {
+	switch incentiveType {
+	case IncentiveFromArticle:
+		return "article"
+	case IncentiveFromGroup:
+		return "group"
+	case IncentiveFromLike:
+		return "like"
+	case IncentiveFromComment:
+		return "comment"
+	case IncentiveFromShare:
+		return "share"
+	case IncentiveFromNode:
+		return "node"
+	default:
+		return "unknown"
+	}
+}
+
+// GetIncentiveStatement builds the statement of the paid incentives of a
+// user. Incentives are dated by the confirmation of their payout batch,
+// days are in UTC.
+func GetIncentiveStatement(userAddress string, from, to time.Time, db *gorm.DB) *IncentiveStatement {
+
+	statement := &IncentiveStatement{}
+	statement.UserAddress = userAddress
+	statement.From = from.UTC().Format(StatementDateFormat)
+	statement.To = to.UTC().Format(StatementDateFormat)
+	statement.Total = decimal.Zero
+
+	paidAt := "COALESCE(incentive_batches.updated_at, incentives.created_at)"
+
+	var incentives []statementIncentive
+
+	in := db.Table("incentives").Joins("LEFT JOIN incentive_batches ON incentive_batches.id = incentives.batch_id")
+	in = in.Where("incentives.user_address = ?", userAddress)
+	in = in.Where("incentives.status = ?", IncentivesPaid)
+	in = in.Where(paidAt + " >= ?", from.Unix())
+	in = in.Where(paidAt + " < ?", to.AddDate(0, 0, 1).Unix())
+	in = in.Select("incentives.incentive_type, incentives.article_dna, incentives.group_dna, incentives.amount" +
+		", " + paidAt + " AS paid_at, COALESCE(incentive_batches.tx_hash, '') AS tx_hash")
+	in = in.Order("paid_at asc")
+	in.Scan(&incentives)
+
+	entries := make(map[string]*IncentiveStatementEntry)
+	totals := make(map[uint]*IncentiveStatementTotal)
+
+	for _, incentive := range incentives {
+
+		date := time.Unix(int64(incentive.PaidAt), 0).UTC().Format(StatementDateFormat)
+		key := date + "/" + strconv.Itoa(int(incentive.IncentiveType))
+
+		entry, ok := entries[key]
+
+		if !ok {
+			entry = &IncentiveStatementEntry{}
+			entry.Date = date
+			entry.IncentiveType = incentive.IncentiveType
+			entry.Type = IncentiveTypeName(incentive.IncentiveType)
+			entry.Amount = decimal.Zero
+
+			entries[key] = entry
+			statement.Entries = append(statement.Entries, entry)
+		}
+
+		entry.Count = entry.Count + 1
+		entry.Amount = entry.Amount.Add(incentive.Amount)
+		entry.ArticleDNAs = appendUnique(entry.ArticleDNAs, incentive.ArticleDNA)
+		entry.GroupDNAs = appendUnique(entry.GroupDNAs, incentive.GroupDNA)
+		entry.TxHashes = appendUnique(entry.TxHashes, incentive.TxHash)
+
+		total, ok := totals[incentive.IncentiveType]
+
+		if !ok {
+			total = &IncentiveStatementTotal{}
+			total.IncentiveType = incentive.IncentiveType
+			total.Type = IncentiveTypeName(incentive.IncentiveType)
+			total.Amount = decimal.Zero
+
+			totals[incentive.IncentiveType] = total
+			statement.Totals = append(statement.Totals, total)
+		}
+
+		total.Count = total.Count + 1
+		total.Amount = total.Amount.Add(incentive.Amount)
+
+		statement.Total = statement.Total.Add(incentive.Amount)
+	}
+
+	sort.SliceStable(statement.Entries, func(i, j int) bool {
+		if statement.Entries[i].Date == statement.Entries[j].Date {
+			return statement.Entries[i].IncentiveType < statement.Entries[j].IncentiveType
+		}
+		return statement.Entries[i].Date < statement.Entries[j].Date
+	})
+
+	sort.Slice(statement.Totals, func(i, j int) bool {
+		return statement.Totals[i].IncentiveType < statement.Totals[j].IncentiveType
+	})
+
+	return statement
+}
+
+// WriteCSV writes one line per entry followed by the totals.
+func (statement *IncentiveStatement) WriteCSV(w io.Writer) error {
+
+	writer := csv.NewWriter(w)
+
+	writer.Write([]string{"date", "type", "count", "amount", "articles", "groups", "tx_hashes"})
+
+	for _, entry := range statement.Entries {
+		writer.Write([]string{
+			entry.Date,
+			entry.Type,
+			strconv.FormatUint(uint64(entry.Count), 10),
+			entry.Amount.String(),
+			strings.Join(entry.ArticleDNAs, ";"),
+			strings.Join(entry.GroupDNAs, ";"),
+			strings.Join(entry.TxHashes, ";") })
+	}
+
+	for _, total := range statement.Totals {
+		writer.Write([]string{
+			"total",
+			total.Type,
+			strconv.FormatUint(uint64(total.Count), 10),
+			total.Amount.String(),
+			"", "", "" })
+	}
+
+	writer.Write([]string{"total", "", "", statement.Total.String(), "", "", ""})
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+func appendUnique(values []string, value string) []string {
+
+	if value == "" {
+		return values
+	}
+
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+
+	return append(values, value)
+}
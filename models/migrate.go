@@ -32,6 +32,27 @@ func MigrateAll () {
 	instance.AutoMigrate(&GroupMember{})
 	instance.AutoMigrate(&GroupArticle{})
 	instance.AutoMigrate(&TokenLock{})
+	instance.AutoMigrate(&TokenLockArchive{})
 	instance.AutoMigrate(&Incentive{})
 	instance.AutoMigrate(&GroupIncentive{})
+	instance.AutoMigrate(&BalanceDrift{})
+	instance.AutoMigrate(&Node{})
+	instance.AutoMigrate(&NodeActivity{})
+	instance.AutoMigrate(&IncentiveBatch{})
+	instance.AutoMigrate(&IncentiveRun{})
+	instance.AutoMigrate(&IncentiveFlag{})
+	instance.AutoMigrate(&HPHistory{})
+	instance.AutoMigrate(&SchedulerLock{})
+	instance.AutoMigrate(&SchedulerJob{})
+	instance.AutoMigrate(&JobRun{})
+	instance.AutoMigrate(&Allowance{})
+	instance.AutoMigrate(&ContractAdminChange{})
+	instance.AutoMigrate(&TokenTransfer{})
+	instance.AutoMigrate(&BurnRequest{})
+	instance.AutoMigrate(&ProfileUpdate{})
+	instance.AutoMigrate(&SignatureNonce{})
+	instance.AutoMigrate(&SignatureNonceUse{})
+	instance.AutoMigrate(&WalletSignature{})
+
+	AdoptLegacyIncentives(instance)
 }
\ No newline at end of file
@@ -0,0 +1,120 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+const NodeActivityArticle = 1
+const NodeActivityInteraction = 2
+
+// Weights of relayed transactions in node scores
+const NodeArticleWeight = 10
+const NodeInteractionWeight = 1
+
+// Node activity is bucketed by block range to measure how continuously a
+// node relayed content. 240 blocks are about one hour.
+const NodeActivityBucketBlocks = 240
+
+// Articles referencing a block further behind the block they are published
+// in were relayed by a node not synchronized with the chain, they are not
+// counted in its score. 240 blocks are about one hour.
+const NodeMaxSyncLagBlocks = 240
+
+// Node is a node operator, keyed by the account it sends transactions from.
+type Node struct {
+	ID                 uint `gorm:"primary_key"`
+	CreatedAt          uint
+	Address            string `gorm:"size:255;unique_index"`
+	ArticleCount       uint `gorm:"default:0"`
+	InteractionCount   uint `gorm:"default:0"`
+	LastActiveBlock    uint64
+}
+
+// NodeActivity is a content transaction relayed by a node.
+type NodeActivity struct {
+	ID                 uint `gorm:"primary_key"`
+	CreatedAt          uint
+	NodeAddress        string `gorm:"size:255;index"`
+	ActivityType       uint `gorm:"index"`
+	TxHash             string `gorm:"size:255;index"`
+	BlockNumber        uint64 `gorm:"index"`
+	SyncLag            uint64
+	Status             uint `gorm:"index"`
+	RunID              uint `gorm:"index"`
+}
+
+type NodeScore struct {
+	NodeAddress        string
+	ArticleCount       uint
+	InteractionCount   uint
+	ActiveBuckets      uint
+}
+
+func IdentifyNode(node *Node, db *gorm.DB) {
+
+	db.Where(&Node{Address: node.Address}).First(node)
+
+	if node.ID == 0 {
+		node.CreatedAt = uint(time.Now().Unix())
+		node.ArticleCount = 0
+		node.InteractionCount = 0
+
+		db.Save(node)
+	}
+}
+
+// RecordNodeActivity registers a content transaction relayed by the node.
+// The same transaction is only recorded once. The sync lag of an article is
+// how many blocks the block it references is behind its own block.
+func RecordNodeActivity(nodeAddress string, activityType uint, txHash string, blockNumber uint64, syncLag uint64, db *gorm.DB) {
+
+	activity := &NodeActivity{ NodeAddress: nodeAddress, ActivityType: activityType, TxHash: txHash }
+
+	db.Where(activity).First(activity)
+
+	if activity.ID != 0 {
+		return
+	}
+
+	activity.CreatedAt = uint(time.Now().Unix())
+	activity.BlockNumber = blockNumber
+	activity.SyncLag = syncLag
+	activity.Status = IncentivesPending
+
+	db.Save(activity)
+
+	node := &Node{ Address: nodeAddress }
+
+	IdentifyNode(node, db)
+
+	db.Set("gorm:query_option", "FOR UPDATE").Where(&Node{Address: nodeAddress}).First(node)
+
+	if activityType == NodeActivityArticle {
+		node.ArticleCount = node.ArticleCount + 1
+	} else {
+		node.InteractionCount = node.InteractionCount + 1
+	}
+
+	if blockNumber > node.LastActiveBlock {
+		node.LastActiveBlock = blockNumber
+	}
+
+	db.Save(node)
+}
@@ -0,0 +1,157 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"time"
+	"github.com/jinzhu/gorm"
+)
+
+const ProfileFieldName = "name"
+const ProfileFieldIcon = "icon"
+
+const ProfileUpdateFailed = 3
+
+// ProfileUpdate is a change of the name or icon of a user submitted to the
+// user contract with the signature of the user for that field. The user
+// keeps the previous value until the transaction is confirmed. The extra
+// data and profile signature of the request, whose updates share the
+// RequestID of the first one, are applied along with the last of its
+// updates so that they always match the name and icon.
+type ProfileUpdate struct {
+	ID               uint `gorm:"primary_key"`
+	CreatedAt        uint
+	UpdatedAt        uint
+	UserAddress      string `gorm:"size:255;index"`
+	RequestID        uint `gorm:"index"`
+	Field            string `gorm:"size:16"`
+	Value            string `gorm:"type:text"`
+	PreviousValue    string `gorm:"type:text"`
+	Signature        string `gorm:"type:text"`
+	Extra            string `gorm:"type:text"`
+	ProfileSignature string `gorm:"type:text"`
+	TxHash           string `gorm:"size:255;index"`
+	TxStatus         int `gorm:"type:int;index"`
+	LastError        string `gorm:"type:text"`
+}
+
+func NewProfileUpdate(user *User, field string, value string, signature string) *ProfileUpdate {
+
+	update := &ProfileUpdate{}
+	update.CreatedAt = uint(time.Now().Unix())
+	update.UpdatedAt = update.CreatedAt
+	update.UserAddress = user.Address
+	update.Field = field
+	update.Value = value
+	update.Signature = signature
+	update.TxStatus = TxStatusPending
+
+	switch field {
+	case ProfileFieldName:
+		update.PreviousValue = user.Name
+	case ProfileFieldIcon:
+		update.PreviousValue = user.Icon
+	}
+
+	return update
+}
+
+// ApplyProfileUpdate applies a name or icon update confirmed on chain,
+// on every node. The update submitted by this node is confirmed with it.
+func ApplyProfileUpdate(userAddress string, field string, value string, txHash string, db *gorm.DB) {
+
+	user := &User{}
+
+	db.Set("gorm:query_option", "FOR UPDATE").Where(&User{ Address: userAddress }).First(user)
+
+	if user.ID == 0 {
+		user.Address = userAddress
+		IdentifyUser(user, db)
+	}
+
+	switch field {
+	case ProfileFieldName:
+		user.Name = value
+	case ProfileFieldIcon:
+		user.Icon = value
+	}
+
+	update := &ProfileUpdate{}
+
+	db.Where(&ProfileUpdate{ TxHash: txHash }).First(update)
+
+	if update.ID != 0 && update.TxStatus == TxStatusPending {
+
+		update.TxStatus = TxStatusConfirmed
+		update.UpdatedAt = uint(time.Now().Unix())
+		db.Save(update)
+
+		// The extra data is signed along with the name and icon, it is
+		// applied once all the updates of the request are confirmed
+
+		unconfirmed := 0
+
+		in := db.Model(&ProfileUpdate{})
+		in = in.Where("request_id = ? AND tx_status <> ?", update.RequestID, TxStatusConfirmed)
+		in.Count(&unconfirmed)
+
+		if unconfirmed == 0 {
+			user.Extra = update.Extra
+			user.Signature = update.ProfileSignature
+		}
+	}
+
+	db.Save(user)
+
+	refreshUserTxStatus(userAddress, db)
+}
+
+// Fail records a failed update, the user keeps the previous value.
+func (update *ProfileUpdate) Fail(reason string, db *gorm.DB) {
+
+	update.TxStatus = ProfileUpdateFailed
+	update.LastError = reason
+	update.UpdatedAt = uint(time.Now().Unix())
+	db.Save(update)
+
+	refreshUserTxStatus(update.UserAddress, db)
+}
+
+// GetProfileHistory lists the name and icon updates of a user, latest
+// first.
+func GetProfileHistory(userAddress string, offset int, db *gorm.DB) []ProfileUpdate {
+
+	var updates []ProfileUpdate
+
+	in := db.Where(&ProfileUpdate{ UserAddress: userAddress })
+	in = in.Order("id desc").Offset(offset).Limit(20)
+	in.Find(&updates)
+
+	return updates
+}
+
+// refreshUserTxStatus marks the user confirmed once no update is pending.
+func refreshUserTxStatus(userAddress string, db *gorm.DB) {
+
+	pending := 0
+
+	db.Model(&ProfileUpdate{}).Where("user_address = ? AND tx_status = ?", userAddress, TxStatusPending).Count(&pending)
+
+	if pending == 0 {
+		db.Model(&User{}).Where("address = ?", userAddress).Update("tx_status", TxStatusConfirmed)
+	}
+}
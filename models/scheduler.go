@@ -0,0 +1,120 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"time"
+	"github.com/jinzhu/gorm"
+)
+
+const SchedulerLockName = "scheduler"
+
+const JobTriggerSchedule = "schedule"
+const JobTriggerManual = "manual"
+
+const JobRunRunning = 1
+const JobRunSucceeded = 2
+const JobRunFailed = 3
+
+// SchedulerLock is the lock row held by the node instance running the
+// scheduled jobs. The holder renews it before it expires, other instances
+// take it over once it has expired.
+type SchedulerLock struct {
+	ID        uint `gorm:"primary_key"`
+	Name      string `gorm:"size:255;unique_index"`
+	Owner     string `gorm:"size:255"`
+	ExpiresAt uint
+}
+
+// SchedulerJob is the state of a job shared by all node instances.
+type SchedulerJob struct {
+	ID               uint `gorm:"primary_key"`
+	Name             string `gorm:"size:255;unique_index"`
+	Paused           bool
+	TriggerRequested bool
+	LastRunAt        uint
+	NextRunAt        uint
+	Failures         uint
+}
+
+// JobRun is the history of job executions.
+type JobRun struct {
+	ID         uint `gorm:"primary_key"`
+	JobName    string `gorm:"size:255;index"`
+	Owner      string `gorm:"size:255"`
+	Trigger    string `gorm:"size:255"`
+	StartedAt  uint
+	FinishedAt uint
+	Status     uint `gorm:"index"`
+	Error      string `gorm:"type:text"`
+}
+
+// AcquireSchedulerLock takes or renews the scheduler lock for the owner and
+// reports whether the owner holds it.
+func AcquireSchedulerLock(owner string, ttl time.Duration, db *gorm.DB) bool {
+
+	now := uint(time.Now().Unix())
+
+	lock := &SchedulerLock{}
+
+	db.Where(&SchedulerLock{ Name: SchedulerLockName }).First(lock)
+
+	if lock.ID == 0 {
+		lock.Name = SchedulerLockName
+
+		// Another instance creating the row at the same time fails on the
+		// unique index
+		if err := db.Create(lock).Error; err != nil {
+			return false
+		}
+	}
+
+	in := db.Model(&SchedulerLock{}).Where("name = ?", SchedulerLockName)
+	in = in.Where("owner = ? OR expires_at < ?", owner, now)
+	in = in.Updates(map[string]interface{}{"owner": owner, "expires_at": now + uint(ttl / time.Second)})
+
+	return in.Error == nil && in.RowsAffected == 1
+}
+
+// HoldsSchedulerLock reports whether the owner holds the scheduler lock and
+// it has not expired.
+func HoldsSchedulerLock(owner string, db *gorm.DB) bool {
+
+	count := 0
+
+	in := db.Model(&SchedulerLock{}).Where("name = ? AND owner = ?", SchedulerLockName, owner)
+	in = in.Where("expires_at >= ?", uint(time.Now().Unix()))
+	in.Count(&count)
+
+	return count == 1
+}
+
+// ReleaseSchedulerLock gives up the lock if the owner holds it.
+func ReleaseSchedulerLock(owner string, db *gorm.DB) {
+	in := db.Model(&SchedulerLock{}).Where("name = ? AND owner = ?", SchedulerLockName, owner)
+	in.Updates(map[string]interface{}{"owner": "", "expires_at": 0})
+}
+
+// GetSchedulerJob returns the state of the job, creating it when missing.
+func GetSchedulerJob(name string, db *gorm.DB) *SchedulerJob {
+
+	job := &SchedulerJob{}
+
+	db.Where(&SchedulerJob{ Name: name }).FirstOrCreate(job)
+
+	return job
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"errors"
+	"time"
+	"github.com/jinzhu/gorm"
+)
+
+// SignatureNonce is the last nonce used by a user in a typed data
+// signature. A signature is only accepted with a greater nonce.
+type SignatureNonce struct {
+	ID          uint `gorm:"primary_key"`
+	UpdatedAt   uint
+	UserAddress string `gorm:"size:255;unique_index"`
+	Nonce       uint64
+}
+
+// GetSignatureNonce returns the last nonce used by the user, 0 if none.
+func GetSignatureNonce(userAddress string, db *gorm.DB) uint64 {
+
+	nonce := &SignatureNonce{}
+
+	db.Where(&SignatureNonce{ UserAddress: userAddress }).First(nonce)
+
+	return nonce.Nonce
+}
+
+// UseSignatureNonce checks the nonce and the deadline of a submitted
+// signature and records the nonce. The deadline may be at most
+// maxDeadlineSeconds ahead.
+func UseSignatureNonce(userAddress string, nonce uint64, deadline uint64, maxDeadlineSeconds int64, db *gorm.DB) error {
+
+	now := uint64(time.Now().Unix())
+
+	if deadline < now {
+		return errors.New("signature expired")
+	}
+
+	if maxDeadlineSeconds > 0 && deadline > now + uint64(maxDeadlineSeconds) {
+		return errors.New("signature deadline too far")
+	}
+
+	record := &SignatureNonce{}
+
+	db.Set("gorm:query_option", "FOR UPDATE").Where(&SignatureNonce{ UserAddress: userAddress }).First(record)
+
+	if nonce <= record.Nonce {
+		return errors.New("nonce already used")
+	}
+
+	record.UserAddress = userAddress
+	record.Nonce = nonce
+	record.UpdatedAt = uint(now)
+
+	return db.Save(record).Error
+}
+
+// RecordSignatureNonce records a nonce seen on chain, which may have been
+// used through another node.
+func RecordSignatureNonce(userAddress string, nonce uint64, db *gorm.DB) {
+
+	record := &SignatureNonce{}
+
+	db.Set("gorm:query_option", "FOR UPDATE").Where(&SignatureNonce{ UserAddress: userAddress }).First(record)
+
+	if nonce <= record.Nonce {
+		return
+	}
+
+	record.UserAddress = userAddress
+	record.Nonce = nonce
+	record.UpdatedAt = uint(time.Now().Unix())
+
+	db.Save(record)
+}
+
+// SignatureNonceUse is a nonce used by an action recorded on chain. A
+// nonce belongs to the first event using it, later events replaying the
+// signature are rejected.
+type SignatureNonceUse struct {
+	ID          uint `gorm:"primary_key"`
+	CreatedAt   uint
+	UserAddress string `gorm:"size:255;unique_index:idx_signature_nonce_use"`
+	Nonce       uint64 `gorm:"unique_index:idx_signature_nonce_use"`
+	TxHash      string `gorm:"size:255"`
+	LogIndex    uint
+}
+
+// UseSignatureNonceOnChain records the use of the nonce by the event of
+// the transaction. It returns false when another event used it.
+func UseSignatureNonceOnChain(userAddress string, nonce uint64, txHash string, logIndex uint, db *gorm.DB) bool {
+
+	use := &SignatureNonceUse{}
+
+	db.Where("user_address = ? AND nonce = ?", userAddress, nonce).First(use)
+
+	if use.ID != 0 {
+		return use.TxHash == txHash && use.LogIndex == logIndex
+	}
+
+	use.CreatedAt = uint(time.Now().Unix())
+	use.UserAddress = userAddress
+	use.Nonce = nonce
+	use.TxHash = txHash
+	use.LogIndex = logIndex
+
+	return db.Create(use).Error == nil
+}
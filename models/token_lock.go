@@ -16,11 +16,26 @@
 
 package models
 
-import "github.com/shopspring/decimal"
+import (
+	"database/sql"
+	"time"
+	"github.com/jinzhu/gorm"
+	"github.com/shopspring/decimal"
+)
 
 const TokenLockResourceGroup = 0
 const TokenLockResourceArticle = 1
 
+// Unlock status of a lock
+const TokenLockUnlockNone = 0
+const TokenLockUnlockSubmitted = 1
+const TokenLockUnlockFailed = 2
+const TokenLockUnlockMined = 3
+
+// TokenLock is tokens of a user locked on a resource. Deposits are
+// recorded with the resource and submitted once its transaction is sent,
+// they are pending until their Lock event is synchronized, those whose
+// transaction failed are dropped.
 type TokenLock struct {
 	ID              uint `gorm:"primary_key"`
 	CreatedAt       uint
@@ -29,4 +44,222 @@ type TokenLock struct {
 	ResourceDNA     string `gorm:"index"`
 	Amount          decimal.Decimal `gorm:"type:decimal(65)"`
 	Expire          uint `gorm:"type:int unsigned;index"`
-}
\ No newline at end of file
+	LockTxHash      string `gorm:"size:255;index"`
+	TxStatus        int `gorm:"type:int;index"`
+	UnlockStatus    uint `gorm:"index;default:0"`
+	UnlockTxHash    string `gorm:"size:255"`
+	UnlockAttempts  uint `gorm:"default:0"`
+	UnlockError     string `gorm:"type:text"`
+}
+
+// TokenLockArchive keeps the locks released on chain.
+type TokenLockArchive struct {
+	ID              uint `gorm:"primary_key"`
+	LockID          uint `gorm:"index"`
+	CreatedAt       uint
+	UserAddress     string `gorm:"size:255;index"`
+	ResourceType    uint
+	ResourceDNA     string `gorm:"index"`
+	Amount          decimal.Decimal `gorm:"type:decimal(65)"`
+	Expire          uint `gorm:"type:int unsigned"`
+	UnlockTxHash    string `gorm:"size:255"`
+	UnlockedAt      uint
+}
+
+// TokenLockInfo is a lock of a user as listed by the API.
+type TokenLockInfo struct {
+	ID               uint
+	CreatedAt        uint
+	ResourceType     uint
+	ResourceTypeName string
+	ResourceDNA      string
+	Amount           decimal.Decimal
+	Expire           uint
+	UnlockStatus     uint
+	UnlockTxHash     string
+	UnlockedAt       uint
+}
+
+// UserTokenLocks lists the locks of a user. Expired locks are waiting to
+// be released on chain, unlocked ones have been released.
+type UserTokenLocks struct {
+	UserAddress string
+	Active      []*TokenLockInfo
+	Expired     []*TokenLockInfo
+	Unlocked    []*TokenLockInfo
+}
+
+// ParseTokenLockResource returns the resource type of a name returned by
+// TokenLockResourceName.
+func ParseTokenLockResource(name string) (uint, bool) {
+	switch name {
+	case "group":
+		return TokenLockResourceGroup, true
+	case "article":
+		return TokenLockResourceArticle, true
+	default:
+		return 0, false
+	}
+}
+
+func TokenLockResourceName(resourceType uint) string {
+	switch resourceType {
+	case TokenLockResourceGroup:
+		return "group"
+	case TokenLockResourceArticle:
+		return "article"
+	default:
+		return "unknown"
+	}
+}
+
+// IsTimeLock reports whether the lock was created by tokenTimeLock.
+// Status locks have no expiry and are released by tokenStatusUnlock.
+func (lock *TokenLock) IsTimeLock() bool {
+	return lock.Expire != 0
+}
+
+// Archive moves the lock to the archive once it is released on chain. It
+// runs in the transaction of db if there is one.
+func (lock *TokenLock) Archive(txHash string, db *gorm.DB) error {
+
+	if _, ok := db.CommonDB().(*sql.Tx); ok {
+		return lock.archive(txHash, db)
+	}
+
+	tx := db.Begin()
+
+	if err := lock.archive(txHash, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+func (lock *TokenLock) archive(txHash string, db *gorm.DB) error {
+
+	archive := &TokenLockArchive{}
+	archive.LockID = lock.ID
+	archive.CreatedAt = lock.CreatedAt
+	archive.UserAddress = lock.UserAddress
+	archive.ResourceType = lock.ResourceType
+	archive.ResourceDNA = lock.ResourceDNA
+	archive.Amount = lock.Amount
+	archive.Expire = lock.Expire
+	archive.UnlockTxHash = txHash
+	archive.UnlockedAt = uint(time.Now().Unix())
+
+	if err := db.Create(archive).Error; err != nil {
+		return err
+	}
+
+	return db.Delete(lock).Error
+}
+
+// GetResourceTokenLock returns the lock of the user on the resource.
+func GetResourceTokenLock(userAddress string, resourceType uint, dna string, db *gorm.DB) *TokenLock {
+
+	lock := &TokenLock{}
+
+	db.Where("user_address = ? AND resource_type = ? AND resource_dna = ?", userAddress, resourceType, dna).First(lock)
+
+	return lock
+}
+
+// GetPendingTokenLocks returns the deposits whose lock transaction is not
+// confirmed yet.
+func GetPendingTokenLocks(db *gorm.DB) []TokenLock {
+
+	var locks []TokenLock
+
+	db.Where("tx_status = ? AND lock_tx_hash <> ''", TxStatusPending).Order("id asc").Find(&locks)
+
+	return locks
+}
+
+// GetUnsentTokenLocks returns the deposits recorded before the given time
+// whose lock transaction was not sent.
+func GetUnsentTokenLocks(before uint, limit int, db *gorm.DB) []TokenLock {
+
+	var locks []TokenLock
+
+	in := db.Where("tx_status = ? AND lock_tx_hash = ''", TxStatusPending)
+	in = in.Where("created_at < ?", before)
+	in.Order("id asc").Limit(limit).Find(&locks)
+
+	return locks
+}
+
+// GetExpiredTokenLocks returns the time locks that expired and are not
+// being released yet, and the failed releases that can be retried.
+func GetExpiredTokenLocks(maxAttempts uint, limit int, db *gorm.DB) []TokenLock {
+
+	var locks []TokenLock
+
+	in := db.Table("token_locks")
+	in = in.Where("expire <> 0 AND expire <= ?", time.Now().Unix())
+	in = in.Where("tx_status <> ?", TxStatusPending)
+	in = in.Where("unlock_status = ? OR ( unlock_status = ? AND unlock_attempts < ? )",
+		TokenLockUnlockNone, TokenLockUnlockFailed, maxAttempts)
+	in = in.Order("expire asc").Limit(limit)
+	in.Find(&locks)
+
+	return locks
+}
+
+// GetUserTokenLocks returns the active, expired and unlocked locks of a
+// user.
+func GetUserTokenLocks(userAddress string, db *gorm.DB) *UserTokenLocks {
+
+	result := &UserTokenLocks{}
+	result.UserAddress = userAddress
+
+	now := uint(time.Now().Unix())
+
+	var locks []TokenLock
+
+	db.Where(&TokenLock{UserAddress: userAddress}).Order("created_at desc").Find(&locks)
+
+	for _, lock := range locks {
+
+		info := &TokenLockInfo{}
+		info.ID = lock.ID
+		info.CreatedAt = lock.CreatedAt
+		info.ResourceType = lock.ResourceType
+		info.ResourceTypeName = TokenLockResourceName(lock.ResourceType)
+		info.ResourceDNA = lock.ResourceDNA
+		info.Amount = lock.Amount
+		info.Expire = lock.Expire
+		info.UnlockStatus = lock.UnlockStatus
+		info.UnlockTxHash = lock.UnlockTxHash
+
+		if lock.Expire == 0 || lock.Expire > now {
+			result.Active = append(result.Active, info)
+		} else {
+			result.Expired = append(result.Expired, info)
+		}
+	}
+
+	var archives []TokenLockArchive
+
+	db.Where(&TokenLockArchive{UserAddress: userAddress}).Order("unlocked_at desc").Find(&archives)
+
+	for _, archive := range archives {
+
+		info := &TokenLockInfo{}
+		info.ID = archive.LockID
+		info.CreatedAt = archive.CreatedAt
+		info.ResourceType = archive.ResourceType
+		info.ResourceTypeName = TokenLockResourceName(archive.ResourceType)
+		info.ResourceDNA = archive.ResourceDNA
+		info.Amount = archive.Amount
+		info.Expire = archive.Expire
+		info.UnlockTxHash = archive.UnlockTxHash
+		info.UnlockedAt = archive.UnlockedAt
+
+		result.Unlocked = append(result.Unlocked, info)
+	}
+
+	return result
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/shopspring/decimal"
+)
+
+// Classification of token transfers
+const TokenTransferMint = 1
+const TokenTransferBurn = 2
+const TokenTransferIncentive = 3
+const TokenTransferUser = 4
+
+// TokenTransfer is a Transfer event of the token contract.
+type TokenTransfer struct {
+	ID             uint `gorm:"primary_key"`
+	CreatedAt      uint
+	BlockNumber    uint64 `gorm:"index"`
+	TxHash         string `gorm:"size:255;index"`
+	LogIndex       uint
+	FromAddress    string `gorm:"size:255;index"`
+	ToAddress      string `gorm:"size:255;index"`
+	Value          decimal.Decimal `gorm:"type:decimal(65)"`
+	Classification uint `gorm:"index"`
+	Type           string `gorm:"-"`
+}
+
+// TokenTransferTypeName returns a readable name of a transfer
+// classification.
+func TokenTransferTypeName(classification uint) string {
+	switch classification {
+	case TokenTransferMint:
+		return "mint"
+	case TokenTransferBurn:
+		return "burn"
+	case TokenTransferIncentive:
+		return "incentive"
+	case TokenTransferUser:
+		return "user"
+	default:
+		return "unknown"
+	}
+}
+
+// RecordTokenTransfer saves the transfer unless its event was already
+// recorded.
+func RecordTokenTransfer(transfer *TokenTransfer, db *gorm.DB) {
+
+	existing := &TokenTransfer{}
+
+	db.Where("tx_hash = ? AND log_index = ?", transfer.TxHash, transfer.LogIndex).First(existing)
+
+	if existing.ID != 0 {
+		return
+	}
+
+	db.Save(transfer)
+}
+
+// GetUserTransfers lists the transfers from or to a user, latest first,
+// optionally limited to a classification.
+func GetUserTransfers(userAddress string, classification uint, offset int, limit int, db *gorm.DB) []TokenTransfer {
+
+	var transfers []TokenTransfer
+
+	in := db.Model(&TokenTransfer{}).Where("( from_address = ? OR to_address = ? )", userAddress, userAddress)
+
+	if classification != 0 {
+		in = in.Where("classification = ?", classification)
+	}
+
+	in = in.Order("block_number desc, log_index desc").Offset(offset).Limit(limit)
+	in.Find(&transfers)
+
+	for i := range transfers {
+		transfers[i].Type = TokenTransferTypeName(transfers[i].Classification)
+	}
+
+	return transfers
+}
@@ -30,8 +30,16 @@ type User struct {
 	Name               string `gorm:"type:text" binding:"required"`
 	Extra              string `gorm:"type:text" binding:"required"`
 	Signature          string `gorm:"type:text" binding:"required"`
+	Icon               string `gorm:"type:text"`
 	Balance            decimal.Decimal `gorm:"type:decimal(65)" binding:"-"`
 	TokenBurned        int    `gorm:"type:tinyint" binding:"-"`
+	TxStatus           int    `gorm:"type:int" binding:"-"`
+
+	// Signatures of the name and icon verified by the user contract, only
+	// required when they change
+
+	NameSignature      string `sql:"-" json:",omitempty"`
+	IconSignature      string `sql:"-" json:",omitempty"`
 
 	// Relations
 
@@ -39,6 +47,37 @@ type User struct {
 	UserGroups         []Group `sql:"-" binding:"-"`
 }
 
+// BalanceCheck compares the balance recorded from Transfer events with the
+// balance held on chain at the synchronized block.
+type BalanceCheck struct {
+	UserAddress        string
+	BlockNumber        string
+	RecordedBalance    decimal.Decimal
+	OnChainBalance     decimal.Decimal
+	Drift              decimal.Decimal
+	HasDrift           bool
+}
+
+func NewBalanceCheck(user *User, blockNumber string, onChainBalance *big.Int) *BalanceCheck {
+
+	check := &BalanceCheck{}
+
+	check.UserAddress = user.Address
+	check.BlockNumber = blockNumber
+	check.RecordedBalance = user.Balance
+	check.OnChainBalance = decimal.NewFromBigInt(onChainBalance, 0)
+	check.Drift = check.RecordedBalance.Sub(check.OnChainBalance)
+	check.HasDrift = check.Drift.Cmp(decimal.Zero) != 0
+
+	return check
+}
+
+// GetSignatureBaseString returns the message signed by the user to update
+// the profile. The icon is optional.
+func (user *User) GetSignatureBaseString () string {
+	return user.Name + user.Extra + user.Icon
+}
+
 func (user *User) GetSpendableBalance(db *gorm.DB) *big.Int {
 
 	recordedBalance := user.Balance.Coefficient()
@@ -69,47 +108,6 @@ func (user *User) GetLockedBalance(db *gorm.DB) *big.Int {
 	return sum
 }
 
-func (user *User) GetHP(db *gorm.DB) *big.Int {
-
-	theta := int64( 5 )            // threshold
-	windowSize := uint( 12 * 3600 ) // window size 12 hours
-
-	thetaInner := big.NewInt(theta)
-	thetaOuter := big.NewInt(theta)
-
-	// Operation count
-	operationCount := 0
-
-	in := db.Table("incentives").Where(&Incentive{UserAddress: user.Address})
-	in = in.Where("created_at > ?", uint(time.Now().Unix()) - windowSize)
-	in.Count(&operationCount)
-
-	balance := user.Balance.Coefficient()
-
-	e := big.NewInt(3) // E = 2.7 = 3
-
-	Cj := big.NewInt(int64(operationCount))
-
-	exp := e.Exp(e, thetaInner.Sub(thetaInner, Cj), nil)
-
-	expA1 := exp.Add(exp, big.NewInt(1))
-
-	CjDivExpA1 :=  Cj.Div(Cj, expA1)
-
-	lower := thetaOuter.Add(thetaOuter, CjDivExpA1)
-
-	lower = lower.Mul(lower, lower)
-
-	hp := balance.Div(balance, lower)
-
-	tenE18 := big.NewInt(10)
-	tenE18 = tenE18.Exp(tenE18, big.NewInt(18), nil)
-
-	hp = hp.Div(hp, tenE18)
-
-	return hp
-}
-
 func IdentifyUser (user *User, db *gorm.DB) {
 
 	db.Where(user).First(&user)
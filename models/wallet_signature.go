@@ -0,0 +1,79 @@
+/*
+ * Copyright 2017 Primas Lab Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package models
+
+import (
+	"time"
+	"github.com/jinzhu/gorm"
+)
+
+// WalletSignature caches the answer of a smart contract wallet asked
+// whether it accepts a signature. The key is the Keccak256 of the wallet
+// address, the signed digest, the signature and the block the wallet was
+// called at, BlockNumber being 0 for the latest block.
+type WalletSignature struct {
+	ID            uint `gorm:"primary_key"`
+	CreatedAt     uint
+	UpdatedAt     uint
+	CacheKey      string `gorm:"size:255;unique_index"`
+	WalletAddress string `gorm:"size:255;index"`
+	BlockNumber   uint64
+	Valid         bool
+}
+
+// GetWalletSignature returns the cached answer for the key. A rejection at
+// the latest block older than maxAgeSeconds is not returned since the
+// owners of the wallet may have changed, an acceptance or an answer at a
+// given block is kept.
+func GetWalletSignature(cacheKey string, maxAgeSeconds int64, db *gorm.DB) *WalletSignature {
+
+	record := &WalletSignature{}
+
+	db.Where(&WalletSignature{ CacheKey: cacheKey }).First(record)
+
+	if record.ID == 0 {
+		return nil
+	}
+
+	if !record.Valid && record.BlockNumber == 0 && int64(record.UpdatedAt) + maxAgeSeconds < time.Now().Unix() {
+		return nil
+	}
+
+	return record
+}
+
+// RecordWalletSignature caches the answer of the wallet.
+func RecordWalletSignature(cacheKey string, walletAddress string, blockNumber uint64, valid bool, db *gorm.DB) {
+
+	record := &WalletSignature{}
+
+	db.Where(&WalletSignature{ CacheKey: cacheKey }).First(record)
+
+	now := uint(time.Now().Unix())
+
+	if record.ID == 0 {
+		record.CreatedAt = now
+	}
+
+	record.UpdatedAt = now
+	record.CacheKey = cacheKey
+	record.WalletAddress = walletAddress
+	record.BlockNumber = blockNumber
+	record.Valid = valid
+
+	db.Save(record)
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/jinzhu/gorm"
 	"errors"
+	"log"
 	"github.com/primasio/primas-node/contracts"
 )
 
@@ -29,35 +30,40 @@ type LogEventHandler interface {
 
 type Dispatcher struct {}
 
-var eventHandlerRegistry map[string]LogEventHandler
+type registeredHandler struct {
+	handler LogEventHandler
+	deployment *contracts.Deployment
+}
+
+var eventHandlerRegistry map[string]*registeredHandler
 
 func (dispatcher *Dispatcher) Init () error {
 
 	// Event handler registry
 	// All handlers must be registered here
 
-	eventHandlerRegistry = make(map[string]LogEventHandler)
+	eventHandlerRegistry = make(map[string]*registeredHandler)
 
 	if contract, err := contracts.GetMetadataContract(); err == nil {
-		eventHandlerRegistry[contract.Contract.Address.Hex()] = contract
+		dispatcher.register(contract.Contract, contract)
 	}else {
 		return err
 	}
 
 	if contract, err := contracts.GetGroupContract(); err == nil {
-		eventHandlerRegistry[contract.Contract.Address.Hex()] = contract
+		dispatcher.register(contract.Contract, contract)
 	}else {
 		return err
 	}
 
 	if contract, err := contracts.GetTokenContract(); err == nil {
-		eventHandlerRegistry[contract.Contract.Address.Hex()] = contract
+		dispatcher.register(contract.Contract, contract)
 	}else {
 		return err
 	}
 
 	if contract, err := contracts.GetUserContract(); err == nil {
-		eventHandlerRegistry[contract.Contract.Address.Hex()] = contract
+		dispatcher.register(contract.Contract, contract)
 	}else {
 		return err
 	}
@@ -65,13 +71,38 @@ func (dispatcher *Dispatcher) Init () error {
 	return nil
 }
 
-func (dispatcher *Dispatcher) DispatchEvent (log *types.Log, db *gorm.DB) error {
+// register routes the events of every deployment of the contract to the
+// handler.
+func (dispatcher *Dispatcher) register (contract *contracts.Contract, handler LogEventHandler) {
+	for _, deployment := range contract.Deployments {
+		eventHandlerRegistry[deployment.Address.Hex()] = &registeredHandler{handler, deployment}
+	}
+}
 
-	addr := log.Address.String()
+func (dispatcher *Dispatcher) DispatchEvent (eventLog *types.Log, db *gorm.DB) error {
 
-	if eventHandlerRegistry[addr] == nil {
+	addr := eventLog.Address.Hex()
+
+	registered := eventHandlerRegistry[addr]
+
+	if registered == nil {
 		return errors.New("log event handler does not exist: " + addr)
 	}
 
-	return eventHandlerRegistry[addr].HandleEvent(log, db)
-}
\ No newline at end of file
+	// Events emitted by a deployment outside its active range are ignored
+
+	if !registered.deployment.IsActiveAt(eventLog.BlockNumber) {
+		return nil
+	}
+
+	err := registered.handler.HandleEvent(eventLog, db)
+
+	// Rejected events are skipped, every node rejects them the same way
+
+	if rejected, ok := err.(*contracts.RejectedEventError); ok {
+		log.Println("skipping event of transaction " + eventLog.TxHash.Hex() + ": " + rejected.Reason)
+		return nil
+	}
+
+	return err
+}
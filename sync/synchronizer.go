@@ -72,14 +72,10 @@ func (synchronizer *BlockSynchronizer) Start() {
 	}
 
 	// Filter
+	// Addresses are set per range from the deployments active in it
 	synchronizer.filter = new(ethereum.FilterQuery)
-	synchronizer.filter.Addresses = [] common.Address{}
 	synchronizer.filter.Topics = [][] common.Hash {}
 
-	for _, ctr := range allContracts {
-		synchronizer.filter.Addresses = append(synchronizer.filter.Addresses, ctr.Address)
-	}
-
 	// Subscribe to block updating
 
 	go func() {
@@ -112,12 +108,25 @@ func (synchronizer *BlockSynchronizer) Start() {
 			// Update latest block hash
 
 			models.SetState("CurrentBlockHash", block.Hash, db.GetDb())
+			models.SetState("LatestBlockNumber", n.String(), db.GetDb())
 
 			err := synchronizer.syncTo(n.Sub(n, big.NewInt(6)))
 
 			if err != nil {
 				log.Println("block synchronization failed: ", err)
 			}
+
+			// Check burn requests and profile updates
+
+			if userContract, err := contracts.GetUserContract(); err == nil {
+				if err := userContract.ProcessBurnRequests(db.GetDb()); err != nil {
+					log.Println("burn request processing failed: ", err)
+				}
+
+				if err := userContract.ProcessProfileUpdates(db.GetDb()); err != nil {
+					log.Println("profile update processing failed: ", err)
+				}
+			}
 		}
 	}
 }
@@ -240,17 +249,38 @@ func (synchronizer *BlockSynchronizer) syncRange(start, end *big.Int) error {
 	// Filter Range
 	synchronizer.filter.FromBlock = start
 	synchronizer.filter.ToBlock = end
+	synchronizer.filter.Addresses = [] common.Address{}
+
+	for _, ctr := range contracts.GetAllContracts() {
+		for _, deployment := range ctr.Deployments {
+			if deployment.IsActiveBetween(start.Uint64(), end.Uint64()) {
+				synchronizer.filter.Addresses = append(synchronizer.filter.Addresses, deployment.Address)
+			}
+		}
+	}
 
 	var logItems []types.Log
 
-	ctx, _ := context.WithTimeout(context.Background(), duration)
+	if len(synchronizer.filter.Addresses) != 0 {
 
-	err := synchronizer.ethClient.CallContext(ctx, &logItems, "eth_getLogs", toFilterArg(*synchronizer.filter))
+		ctx, _ := context.WithTimeout(context.Background(), duration)
 
-	if err != nil {
+		err := synchronizer.ethClient.CallContext(ctx, &logItems, "eth_getLogs", toFilterArg(*synchronizer.filter))
+
+		if err != nil {
+			return err
+		}
+	}
+
+	// Block times, relayers and referenced blocks are read from the
+	// Ethereum node before the transaction is opened
+
+	if err := contracts.PrefetchChainData(logItems); err != nil {
 		return err
 	}
 
+	defer contracts.ClearChainData()
+
 	// Process log items in a transaction
 
 	// Start transaction